@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/google/uuid"
+)
+
+// Blocks work in both directions: neither user sees the other's chirps, can
+// reply to, share or follow them, and any follow between them is removed.
+// Mutes only hide the muted user's chirps from the muter's lists, and
+// nothing tells the muted user about it. Both are enforced in the chirp
+// queries themselves, so every read path applies them the same way.
+
+var errFollowBlocked = errors.New("follow between blocked users")
+
+type userRelation struct {
+	UserId    string `json:"user_id"`
+	CreatedAt string `json:"created_at"`
+}
+
+// targetUser reads the user a block or mute request is about, answering
+// with an error itself when the request can't go on.
+func (cfg *apiConfig) targetUser(w http.ResponseWriter, req *http.Request) (userId, targetId uuid.UUID, ok bool) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating: %v", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	targetId, err = uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userId, targetId, true
+}
+
+func (cfg *apiConfig) blockUser(w http.ResponseWriter, req *http.Request) {
+	userId, blockedId, ok := cfg.targetUser(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.withTx(context.Background(), func(q *database.Queries) error {
+		err := q.CreateBlock(context.Background(), database.CreateBlockParams{
+			BlockerID: userId,
+			BlockedID: blockedId,
+		})
+		if err != nil {
+			return err
+		}
+
+		if _, err := stopFollowing(context.Background(), q, blockedId, userId); err != nil {
+			return err
+		}
+		_, err = stopFollowing(context.Background(), q, userId, blockedId)
+		return err
+	})
+	if isCheckViolation(err) {
+		respondWithError(w, http.StatusBadRequest, "You can not block yourself")
+		return
+	}
+	if isForeignKeyViolation(err) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error blocking user")
+		log.Printf("Error creating block: %v", err)
+		return
+	}
+
+	cfg.liveHiddenChanged(userId, blockedId)
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) unblockUser(w http.ResponseWriter, req *http.Request) {
+	userId, blockedId, ok := cfg.targetUser(w, req)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteBlock(context.Background(), database.DeleteBlockParams{
+		BlockerID: userId,
+		BlockedID: blockedId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error unblocking user")
+		log.Printf("Error deleting block: %v", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "User is not blocked")
+		return
+	}
+
+	cfg.liveHiddenChanged(userId, blockedId)
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) muteUser(w http.ResponseWriter, req *http.Request) {
+	userId, mutedId, ok := cfg.targetUser(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.CreateMute(context.Background(), database.CreateMuteParams{
+		MuterID: userId,
+		MutedID: mutedId,
+	})
+	if isCheckViolation(err) {
+		respondWithError(w, http.StatusBadRequest, "You can not mute yourself")
+		return
+	}
+	if isForeignKeyViolation(err) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error muting user")
+		log.Printf("Error creating mute: %v", err)
+		return
+	}
+
+	cfg.liveHiddenChanged(userId, mutedId)
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) unmuteUser(w http.ResponseWriter, req *http.Request) {
+	userId, mutedId, ok := cfg.targetUser(w, req)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteMute(context.Background(), database.DeleteMuteParams{
+		MuterID: userId,
+		MutedID: mutedId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error unmuting user")
+		log.Printf("Error deleting mute: %v", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "User is not muted")
+		return
+	}
+
+	cfg.liveHiddenChanged(userId, mutedId)
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) getBlocks(w http.ResponseWriter, req *http.Request) {
+	cfg.listRelations(w, req, func(ctx context.Context, userId uuid.UUID, limit int32, cursor pageCursor) ([]userLink, error) {
+		blocksDb, err := cfg.db.GetBlocks(ctx, database.GetBlocksParams{
+			BlockerID:       userId,
+			BeforeCreatedAt: cursor.CreatedAt,
+			BeforeID:        cursor.ID,
+			PageSize:        limit,
+		})
+		var rows []userLink
+		for _, blockDb := range blocksDb {
+			rows = append(rows, userLink{userId: blockDb.BlockedID, createdAt: blockDb.CreatedAt})
+		}
+		return rows, err
+	})
+}
+
+func (cfg *apiConfig) getMutes(w http.ResponseWriter, req *http.Request) {
+	cfg.listRelations(w, req, func(ctx context.Context, userId uuid.UUID, limit int32, cursor pageCursor) ([]userLink, error) {
+		mutesDb, err := cfg.db.GetMutes(ctx, database.GetMutesParams{
+			MuterID:         userId,
+			BeforeCreatedAt: cursor.CreatedAt,
+			BeforeID:        cursor.ID,
+			PageSize:        limit,
+		})
+		var rows []userLink
+		for _, muteDb := range mutesDb {
+			rows = append(rows, userLink{userId: muteDb.MutedID, createdAt: muteDb.CreatedAt})
+		}
+		return rows, err
+	})
+}
+
+// listRelations answers with one page of the users the caller has blocked or
+// muted, most recent first.
+func (cfg *apiConfig) listRelations(w http.ResponseWriter, req *http.Request, load func(context.Context, uuid.UUID, int32, pageCursor) ([]userLink, error)) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating: %v", err)
+		return
+	}
+
+	limit, cursor, err := pageParams(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := load(context.Background(), userId, limit, cursor)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting users")
+		log.Printf("Error getting relations: %v", err)
+		return
+	}
+
+	relations := page[userRelation]{Items: []userRelation{}}
+	for _, row := range rows {
+		relations.Items = append(relations.Items, userRelation{
+			UserId:    row.userId.String(),
+			CreatedAt: row.createdAt.String(),
+		})
+	}
+	if len(rows) == int(limit) {
+		last := rows[len(rows)-1]
+		relations.NextCursor = pageCursor{CreatedAt: last.createdAt, ID: last.userId}.String()
+	}
+
+	respondWithJSON(w, http.StatusOK, relations)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/google/uuid"
+)
+
+// Bookmarks are private to the user who made them. Nothing reports who
+// bookmarked a chirp, not even to its author.
+
+func (cfg *apiConfig) addBookmark(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating bookmark: %v", err)
+		return
+	}
+
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	if _, err := cfg.db.GetChirpById(context.Background(), database.GetChirpByIdParams{
+		ID:       chirpId,
+		ViewerID: userId,
+	}); err != nil {
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	}
+
+	err = cfg.db.CreateBookmark(context.Background(), database.CreateBookmarkParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error saving bookmark")
+		log.Printf("Error creating bookmark: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) removeBookmark(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating bookmark: %v", err)
+		return
+	}
+
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	err = cfg.db.DeleteBookmark(context.Background(), database.DeleteBookmarkParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error removing bookmark")
+		log.Printf("Error deleting bookmark: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// getBookmarks lists the user's bookmarks, most recently saved first.
+// Bookmarks of chirps that were deleted or are no longer visible to the
+// user are skipped; deleting the chirp for good removes them.
+func (cfg *apiConfig) getBookmarks(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating bookmarks: %v", err)
+		return
+	}
+
+	limit, cursor, err := pageParams(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	bookmarksDb, err := cfg.db.GetBookmarksForUser(context.Background(), database.GetBookmarksForUserParams{
+		UserID:          userId,
+		BeforeCreatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		PageSize:        limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting bookmarks")
+		log.Printf("Error getting bookmarks: %v", err)
+		return
+	}
+
+	var chirpIds []uuid.UUID
+	for _, bookmarkDb := range bookmarksDb {
+		chirpIds = append(chirpIds, bookmarkDb.ChirpID)
+	}
+
+	chirpsDb, err := cfg.chirpsInOrder(context.Background(), userId, chirpIds)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting bookmarks")
+		log.Printf("Error getting bookmarked chirps: %v", err)
+		return
+	}
+
+	chirpsJson, err := cfg.chirpsResponse(context.Background(), userId, chirpsDb)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading chirp details")
+		log.Printf("Error loading chirp details: %v", err)
+		return
+	}
+
+	chirpsJson, err = cfg.filterSensitive(context.Background(), userId, chirpsJson)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading preferences")
+		log.Printf("Error loading preferences: %v", err)
+		return
+	}
+
+	bookmarks := page[chirp]{Items: []chirp{}}
+	bookmarks.Items = append(bookmarks.Items, chirpsJson...)
+	if len(bookmarksDb) == int(limit) {
+		last := bookmarksDb[len(bookmarksDb)-1]
+		bookmarks.NextCursor = pageCursor{CreatedAt: last.CreatedAt, ID: last.ChirpID}.String()
+	}
+
+	respondWithJSON(w, http.StatusOK, bookmarks)
+}
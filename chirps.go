@@ -0,0 +1,720 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/AhmettCelik/web-server/internal/entities"
+	"github.com/AhmettCelik/web-server/internal/textlen"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultChirpLengthLimit = 140
+	chirpyRedLengthLimit    = 280
+	chirpURLWeight          = 23
+	maxContentWarningLength = 100
+)
+
+const (
+	chirpKindChirp   = "chirp"
+	chirpKindRechirp = "rechirp"
+	chirpKindQuote   = "quote"
+)
+
+// Followers-only chirps are visible to their author and the author's
+// followers. Every chirp read checks visibility in its query.
+const (
+	chirpVisibilityPublic    = "public"
+	chirpVisibilityFollowers = "followers"
+	chirpVisibilityPrivate   = "private"
+)
+
+func validVisibility(visibility string) bool {
+	switch visibility {
+	case chirpVisibilityPublic, chirpVisibilityFollowers, chirpVisibilityPrivate:
+		return true
+	}
+	return false
+}
+
+var (
+	errReferencedChirpNotFound  = errors.New("referenced chirp not found")
+	errReferencedChirpNotPublic = errors.New("referenced chirp is not public")
+	errChirpTooLong             = errors.New("Chirp is too long")
+	errChirpRejected            = errors.New("Chirp contains blocked words")
+)
+
+// chirpTombstone stands in for a chirp that was deleted, so clients following
+// a reference can tell a deleted chirp apart from one that never existed.
+type chirpTombstone struct {
+	Id        string `json:"id"`
+	Deleted   bool   `json:"deleted"`
+	DeletedAt string `json:"deleted_at"`
+}
+
+func tombstoneFromDb(chirpDb database.Chirp) chirpTombstone {
+	return chirpTombstone{
+		Id:        chirpDb.ID.String(),
+		Deleted:   true,
+		DeletedAt: chirpDb.DeletedAt.Time.String(),
+	}
+}
+
+// chirpError is a problem with a chirp that the client can fix, along with
+// the status code it is reported with. When details is set it is sent as JSON
+// instead of the plain message.
+type chirpError struct {
+	status  int
+	msg     string
+	details any
+}
+
+func (e *chirpError) Error() string {
+	return e.msg
+}
+
+func respondWithChirpError(w http.ResponseWriter, chirpErr *chirpError) {
+	if chirpErr.details != nil {
+		respondWithJSON(w, chirpErr.status, chirpErr.details)
+		return
+	}
+	respondWithError(w, chirpErr.status, chirpErr.msg)
+}
+
+// chirpLengthError is sent when a chirp is over the limit, so clients can
+// show a counter that matches what the server enforces.
+type chirpLengthError struct {
+	Error string `json:"error"`
+	Limit int    `json:"limit"`
+	Count int    `json:"count"`
+}
+
+// preparedChirp is a chirp that passed prepareChirp and is ready to be
+// stored with insertChirp.
+type preparedChirp struct {
+	params   database.CreateChirpParams
+	mediaIds []uuid.UUID
+	poll     *preparedPoll
+	flagged  []string
+}
+
+// prepareChirp runs every check a new chirp goes through without writing
+// anything, so callers can report problems before publishing.
+func (cfg *apiConfig) prepareChirp(ctx context.Context, userId uuid.UUID, post interpreter) (preparedChirp, error) {
+	limit, err := cfg.chirpLengthLimit(ctx, userId)
+	if err != nil {
+		return preparedChirp{}, err
+	}
+
+	cleanedBody, flagged, err := cfg.cleanChirpBody(post.Body, limit)
+	if err != nil {
+		return preparedChirp{}, err
+	}
+
+	kind := post.Kind
+	if kind == "" {
+		kind = chirpKindChirp
+	}
+
+	if len(post.MediaIDs) > maxChirpMedia {
+		return preparedChirp{}, &chirpError{status: http.StatusBadRequest, msg: fmt.Sprintf("A chirp can have at most %d media attachments", maxChirpMedia)}
+	}
+
+	var mediaIds []uuid.UUID
+	for _, id := range post.MediaIDs {
+		mediaId, err := uuid.Parse(id)
+		if err != nil {
+			return preparedChirp{}, &chirpError{status: http.StatusBadRequest, msg: "Cant parse media id"}
+		}
+		mediaIds = append(mediaIds, mediaId)
+	}
+
+	visibility := post.Visibility
+	if visibility == "" {
+		visibility = chirpVisibilityPublic
+	}
+	if !validVisibility(visibility) {
+		return preparedChirp{}, &chirpError{status: http.StatusBadRequest, msg: "Unknown visibility"}
+	}
+
+	contentWarning := strings.TrimSpace(post.ContentWarning)
+	if contentWarning != "" {
+		if textlen.Graphemes(contentWarning) > maxContentWarningLength {
+			return preparedChirp{}, &chirpError{status: http.StatusBadRequest, msg: fmt.Sprintf("Content warnings can be at most %d characters", maxContentWarningLength)}
+		}
+
+		result := cfg.contentFilter.Load().Apply(contentWarning)
+		if result.Rejected {
+			return preparedChirp{}, &chirpError{status: http.StatusBadRequest, msg: errChirpRejected.Error()}
+		}
+		contentWarning = result.Text
+		flagged = append(flagged, result.Flagged...)
+	}
+
+	var publishAt sql.NullTime
+	if post.PublishAt != nil {
+		if !post.PublishAt.After(time.Now()) {
+			return preparedChirp{}, &chirpError{status: http.StatusBadRequest, msg: "publish_at must be in the future"}
+		}
+		publishAt = sql.NullTime{Time: post.PublishAt.Local(), Valid: true}
+	}
+
+	var prepared *preparedPoll
+	if post.Poll != nil {
+		if kind == chirpKindRechirp {
+			return preparedChirp{}, &chirpError{status: http.StatusBadRequest, msg: "Rechirps can not have a poll"}
+		}
+
+		var pollFlagged []string
+		prepared, pollFlagged, err = cfg.preparePoll(post.Poll, publishAt)
+		if err != nil {
+			return preparedChirp{}, err
+		}
+		flagged = append(flagged, pollFlagged...)
+	}
+
+	var inReplyToID uuid.NullUUID
+	if post.InReplyToID != "" {
+		parentId, err := uuid.Parse(post.InReplyToID)
+		if err != nil {
+			return preparedChirp{}, &chirpError{status: http.StatusBadRequest, msg: "Cant parse in_reply_to_id"}
+		}
+
+		_, err = cfg.db.GetChirpById(ctx, database.GetChirpByIdParams{
+			ID:       parentId,
+			ViewerID: userId,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return preparedChirp{}, &chirpError{status: http.StatusNotFound, msg: "Replied to chirp not found"}
+		}
+		if err != nil {
+			return preparedChirp{}, err
+		}
+		inReplyToID = uuid.NullUUID{UUID: parentId, Valid: true}
+	}
+
+	var referencedChirpID uuid.NullUUID
+	switch kind {
+	case chirpKindChirp:
+	case chirpKindRechirp, chirpKindQuote:
+		if kind == chirpKindRechirp && (post.Body != "" || len(mediaIds) > 0) {
+			return preparedChirp{}, &chirpError{status: http.StatusBadRequest, msg: "Rechirps can not have a body or media"}
+		}
+		if kind == chirpKindRechirp && (contentWarning != "" || post.Sensitive) {
+			return preparedChirp{}, &chirpError{status: http.StatusBadRequest, msg: "Rechirps use the content warning of the chirp they share"}
+		}
+		if kind == chirpKindQuote && post.Body == "" {
+			return preparedChirp{}, &chirpError{status: http.StatusBadRequest, msg: "Quote chirps need a body"}
+		}
+
+		referencedChirpID, err = cfg.resolveReferencedChirp(ctx, userId, post.ReferencedChirpID)
+		if errors.Is(err, errReferencedChirpNotFound) {
+			return preparedChirp{}, &chirpError{status: http.StatusNotFound, msg: "Referenced chirp not found"}
+		}
+		if errors.Is(err, errReferencedChirpNotPublic) {
+			return preparedChirp{}, &chirpError{status: http.StatusBadRequest, msg: "Only public chirps can be rechirped or quoted"}
+		}
+		if err != nil {
+			log.Printf("Error resolving referenced chirp: %v", err)
+			return preparedChirp{}, &chirpError{status: http.StatusBadRequest, msg: "Invalid referenced chirp"}
+		}
+	default:
+		return preparedChirp{}, &chirpError{status: http.StatusBadRequest, msg: "Unknown chirp kind"}
+	}
+
+	return preparedChirp{
+		params: database.CreateChirpParams{
+			UserID:            userId,
+			Body:              cleanedBody,
+			Kind:              kind,
+			ReferencedChirpID: referencedChirpID,
+			Scheduled:         publishAt.Valid,
+			PublishAt:         publishAt,
+			Visibility:        visibility,
+			ContentWarning:    contentWarning,
+			Sensitive:         post.Sensitive,
+			InReplyToID:       inReplyToID,
+		},
+		mediaIds: mediaIds,
+		poll:     prepared,
+		flagged:  flagged,
+	}, nil
+}
+
+// insertChirp stores a prepared chirp along with its entities and media, and
+// publishes it unless it is scheduled. It should run inside a transaction so
+// a failure leaves nothing behind.
+func insertChirp(ctx context.Context, q *database.Queries, prepared preparedChirp) (database.Chirp, error) {
+	chirpDb, err := q.CreateChirp(ctx, prepared.params)
+	if isUniqueViolation(err) {
+		return database.Chirp{}, &chirpError{status: http.StatusConflict, msg: "Chirp already rechirped"}
+	}
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if err := saveChirpEntities(ctx, q, chirpDb.ID, entities.Parse(chirpDb.Body)); err != nil {
+		return database.Chirp{}, err
+	}
+
+	if err := saveChirpFlags(ctx, q, chirpDb.ID, prepared.flagged); err != nil {
+		return database.Chirp{}, err
+	}
+
+	if prepared.poll != nil {
+		if err := insertPoll(ctx, q, chirpDb.ID, prepared.poll); err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	if !chirpDb.Scheduled {
+		if err := publishChirp(ctx, q, chirpDb); err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	err = attachChirpMedia(ctx, q, chirpDb.ID, chirpDb.UserID, prepared.mediaIds)
+	if errors.Is(err, errMediaUnavailable) {
+		return database.Chirp{}, &chirpError{status: http.StatusBadRequest, msg: "Media can not be attached"}
+	}
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	return chirpDb, nil
+}
+
+// publishChirp does everything that happens when a chirp becomes visible:
+// it is fanned out to home timelines and the users it involves are notified.
+func publishChirp(ctx context.Context, q *database.Queries, chirpDb database.Chirp) error {
+	if err := fanOutChirp(ctx, q, chirpDb); err != nil {
+		return err
+	}
+
+	events, err := chirpEvents(ctx, q, chirpDb)
+	if err != nil {
+		return err
+	}
+	for _, ev := range events {
+		if err := recordEvent(ctx, q, ev); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// chirpLengthLimit returns how long the user's chirps may be. Chirpy Red
+// members get a longer limit.
+func (cfg *apiConfig) chirpLengthLimit(ctx context.Context, userId uuid.UUID) (int, error) {
+	userDb, err := cfg.db.GetUserById(ctx, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	if userDb.IsChirpyRed.Bool {
+		return chirpyRedLengthLimit, nil
+	}
+	return defaultChirpLengthLimit, nil
+}
+
+// cleanChirpBody runs the checks every chirp body goes through before it is
+// stored. It returns the body with masked words replaced and the terms that
+// should flag the chirp for review. Length is counted in graphemes with URLs
+// at a fixed weight, so emoji and non-ASCII text are not penalized.
+func (cfg *apiConfig) cleanChirpBody(body string, limit int) (string, []string, error) {
+	count := textlen.Weighted(body, chirpURLWeight)
+	if count > limit {
+		return "", nil, &chirpError{
+			status:  http.StatusBadRequest,
+			msg:     errChirpTooLong.Error(),
+			details: chirpLengthError{Error: errChirpTooLong.Error(), Limit: limit, Count: count},
+		}
+	}
+
+	result := cfg.contentFilter.Load().Apply(body)
+	if result.Rejected {
+		return "", nil, &chirpError{status: http.StatusBadRequest, msg: errChirpRejected.Error()}
+	}
+
+	return result.Text, result.Flagged, nil
+}
+
+func chirpFromDb(chirpDb database.Chirp) chirp {
+	chirpJson := chirp{
+		Id:             chirpDb.ID.String(),
+		CreatedAt:      chirpDb.CreatedAt.String(),
+		UpdatedAt:      chirpDb.UpdatedAt.String(),
+		Body:           chirpDb.Body,
+		UserId:         chirpDb.UserID.String(),
+		Kind:           chirpDb.Kind,
+		Visibility:     chirpDb.Visibility,
+		ContentWarning: chirpDb.ContentWarning,
+		Sensitive:      chirpDb.Sensitive,
+		Entities:       []chirpEntity{},
+		Media:          []chirpMedia{},
+	}
+	if chirpDb.InReplyToID.Valid {
+		chirpJson.InReplyToId = chirpDb.InReplyToID.UUID.String()
+	}
+	if chirpDb.Scheduled {
+		chirpJson.PublishAt = chirpDb.PublishAt.Time.String()
+	}
+	return chirpJson
+}
+
+// resolveReferencedChirp looks up the chirp a rechirp or quote points at, as
+// userId sees it. Rechirps are followed back to their original, so sharing a
+// rechirp shares the chirp it reposted instead of building a chain of empty
+// reposts. Only public chirps can be shared, so sharing never widens who can
+// see a chirp.
+func (cfg *apiConfig) resolveReferencedChirp(ctx context.Context, userId uuid.UUID, id string) (uuid.NullUUID, error) {
+	chirpId, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("invalid referenced chirp id: %w", err)
+	}
+
+	referenced, err := cfg.db.GetChirpById(ctx, database.GetChirpByIdParams{
+		ID:       chirpId,
+		ViewerID: userId,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.NullUUID{}, errReferencedChirpNotFound
+	}
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	if referenced.Kind == chirpKindRechirp {
+		if !referenced.ReferencedChirpID.Valid {
+			return uuid.NullUUID{}, errReferencedChirpNotFound
+		}
+		return cfg.resolveReferencedChirp(ctx, userId, referenced.ReferencedChirpID.UUID.String())
+	}
+
+	if referenced.Visibility != chirpVisibilityPublic {
+		return uuid.NullUUID{}, errReferencedChirpNotPublic
+	}
+
+	return uuid.NullUUID{UUID: referenced.ID, Valid: true}, nil
+}
+
+// chirpsResponse converts chirps to their JSON form as viewerId sees them,
+// with uuid.Nil for an anonymous viewer. The chirp each rechirp or quote
+// refers to is embedded, and entities, media and polls are attached to every
+// chirp, embedded ones included. Each is loaded with one query for the whole
+// page.
+// When an original has been deleted the response keeps its id and is
+// flagged instead, so clients can render a tombstone. An original the viewer
+// is not allowed to see is left out without saying why.
+func (cfg *apiConfig) chirpsResponse(ctx context.Context, viewerId uuid.UUID, chirpsDb []database.Chirp) ([]chirp, error) {
+	var ids, referencedIds, authorIds []uuid.UUID
+	for _, chirpDb := range chirpsDb {
+		ids = append(ids, chirpDb.ID)
+		authorIds = append(authorIds, chirpDb.UserID)
+		if chirpDb.ReferencedChirpID.Valid {
+			referencedIds = append(referencedIds, chirpDb.ReferencedChirpID.UUID)
+		}
+	}
+
+	referenced := map[uuid.UUID]database.Chirp{}
+	if len(referencedIds) > 0 {
+		referencedDb, err := cfg.db.GetChirpsByIds(ctx, database.GetChirpsByIdsParams{
+			Ids:      referencedIds,
+			ViewerID: viewerId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, chirpDb := range referencedDb {
+			referenced[chirpDb.ID] = chirpDb
+			authorIds = append(authorIds, chirpDb.UserID)
+		}
+	}
+
+	authors, err := cfg.userProfiles(ctx, authorIds)
+	if err != nil {
+		return nil, err
+	}
+
+	entitiesByChirp := map[uuid.UUID][]chirpEntity{}
+	if len(ids) > 0 {
+		entitiesDb, err := cfg.db.GetEntitiesForChirps(ctx, append(ids, referencedIds...))
+		if err != nil {
+			return nil, err
+		}
+		for _, entityDb := range entitiesDb {
+			entitiesByChirp[entityDb.ChirpID] = append(entitiesByChirp[entityDb.ChirpID], chirpEntity{
+				Type:  entityDb.Kind,
+				Start: int(entityDb.StartOffset),
+				End:   int(entityDb.EndOffset),
+				Text:  entityDb.Text,
+				Value: entityDb.Value,
+			})
+		}
+	}
+
+	mediaByChirp := map[uuid.UUID][]chirpMedia{}
+	if len(ids) > 0 {
+		mediaDb, err := cfg.db.GetMediaForChirps(ctx, append(ids, referencedIds...))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range mediaDb {
+			mediaByChirp[m.ChirpID.UUID] = append(mediaByChirp[m.ChirpID.UUID], mediaFromDb(m))
+		}
+	}
+
+	pollsByChirp := map[uuid.UUID]*poll{}
+	if len(ids) > 0 {
+		var err error
+		pollsByChirp, err = cfg.pollsForChirps(ctx, viewerId, append(ids, referencedIds...))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	withDetails := func(chirpDb database.Chirp) chirp {
+		chirpJson := chirpFromDb(chirpDb)
+		if found, ok := entitiesByChirp[chirpDb.ID]; ok {
+			chirpJson.Entities = found
+		}
+		if found, ok := mediaByChirp[chirpDb.ID]; ok {
+			chirpJson.Media = found
+		}
+		chirpJson.Poll = pollsByChirp[chirpDb.ID]
+		if profileDb, ok := authors[chirpDb.UserID]; ok {
+			authorJson := authorFromDb(profileDb)
+			chirpJson.Author = &authorJson
+		}
+		return chirpJson
+	}
+
+	var chirpsJson []chirp
+	for _, chirpDb := range chirpsDb {
+		chirpJson := withDetails(chirpDb)
+		if chirpDb.ReferencedChirpID.Valid {
+			chirpJson.ReferencedChirpId = chirpDb.ReferencedChirpID.UUID.String()
+		}
+		if chirpDb.Kind != chirpKindChirp {
+			original, ok := referenced[chirpDb.ReferencedChirpID.UUID]
+			switch {
+			case !chirpDb.ReferencedChirpID.Valid || ok && original.DeletedAt.Valid:
+				chirpJson.ReferencedChirpDeleted = true
+			case ok:
+				originalJson := withDetails(original)
+				chirpJson.ReferencedChirp = &originalJson
+			}
+		}
+		chirpsJson = append(chirpsJson, chirpJson)
+	}
+
+	return chirpsJson, nil
+}
+
+func (cfg *apiConfig) chirpResponse(ctx context.Context, viewerId uuid.UUID, chirpDb database.Chirp) (chirp, error) {
+	chirpsJson, err := cfg.chirpsResponse(ctx, viewerId, []database.Chirp{chirpDb})
+	if err != nil {
+		return chirp{}, err
+	}
+	return chirpsJson[0], nil
+}
+
+// saveChirpEntities stores the entities parsed from a chirp body, creating
+// hashtags the first time they are used.
+func saveChirpEntities(ctx context.Context, q *database.Queries, chirpId uuid.UUID, found []entities.Entity) error {
+	for _, entity := range found {
+		params := database.CreateChirpEntityParams{
+			ChirpID:     chirpId,
+			Kind:        entity.Kind,
+			StartOffset: int32(entity.Start),
+			EndOffset:   int32(entity.End),
+			Text:        entity.Text,
+			Value:       entity.Value,
+		}
+
+		if entity.Kind == entities.KindHashtag {
+			hashtag, err := q.UpsertHashtag(ctx, entity.Value)
+			if err != nil {
+				return err
+			}
+			params.HashtagID = uuid.NullUUID{UUID: hashtag.ID, Valid: true}
+		}
+
+		if err := q.CreateChirpEntity(ctx, params); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cfg *apiConfig) getHashtagChirps(w http.ResponseWriter, req *http.Request) {
+	viewerId, err := cfg.viewer(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	tag := entities.NormalizeHashtag(req.PathValue("tag"))
+
+	chirpsDb, err := cfg.db.GetChirpsForHashtag(context.Background(), database.GetChirpsForHashtagParams{
+		Tag:      tag,
+		ViewerID: viewerId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirps")
+		log.Printf("Error getting chirps for hashtag: %v", err)
+		return
+	}
+
+	chirpsJson, err := cfg.chirpsResponse(context.Background(), viewerId, chirpsDb)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading chirp details")
+		log.Printf("Error loading chirp details: %v", err)
+		return
+	}
+
+	chirpsJson, err = cfg.filterSensitive(context.Background(), viewerId, chirpsJson)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading preferences")
+		log.Printf("Error loading preferences: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirpsJson)
+}
+
+// restoreChirp undoes a delete by the chirp's owner, as long as the chirp
+// is still within the restore window and has not been purged.
+func (cfg *apiConfig) restoreChirp(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating restore: %v", err)
+		return
+	}
+
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	chirpDb, err := cfg.db.RestoreChirp(context.Background(), database.RestoreChirpParams{
+		ID:           chirpId,
+		UserID:       userId,
+		DeletedAfter: time.Now().Add(-cfg.restoreWindow),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "No deleted chirp to restore")
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Chirp already rechirped")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error restoring chirp")
+		log.Printf("Error restoring chirp: %v", err)
+		return
+	}
+	cfg.streamChirpsCreated(context.Background(), []database.Chirp{chirpDb})
+
+	chirpJson, err := cfg.chirpResponse(context.Background(), userId, chirpDb)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading chirp details")
+		log.Printf("Error loading chirp details: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirpJson)
+}
+
+// updateChirpVisibility changes who can see a chirp. Every read checks
+// visibility in its query, so the change applies to the next request.
+func (cfg *apiConfig) updateChirpVisibility(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating visibility change: %v", err)
+		return
+	}
+
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	update := struct {
+		Visibility string `json:"visibility"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if !validVisibility(update.Visibility) {
+		respondWithError(w, http.StatusBadRequest, "Unknown visibility")
+		return
+	}
+
+	chirpDb, err := cfg.db.UpdateChirpVisibility(context.Background(), database.UpdateChirpVisibilityParams{
+		Visibility: update.Visibility,
+		ID:         chirpId,
+		UserID:     userId,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating chirp")
+		log.Printf("Error updating chirp visibility: %v", err)
+		return
+	}
+
+	chirpJson, err := cfg.chirpResponse(context.Background(), userId, chirpDb)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading chirp details")
+		log.Printf("Error loading chirp details: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirpJson)
+}
+
+// chirpsInOrder loads chirps by id as viewerId sees them, keeping the order
+// of the ids. Chirps that are deleted or hidden from the viewer are dropped.
+func (cfg *apiConfig) chirpsInOrder(ctx context.Context, viewerId uuid.UUID, ids []uuid.UUID) ([]database.Chirp, error) {
+	found, err := cfg.db.GetChirpsByIds(ctx, database.GetChirpsByIdsParams{
+		Ids:      ids,
+		ViewerID: viewerId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byId := map[uuid.UUID]database.Chirp{}
+	for _, chirpDb := range found {
+		byId[chirpDb.ID] = chirpDb
+	}
+
+	var chirpsDb []database.Chirp
+	for _, chirpId := range ids {
+		if chirpDb, ok := byId[chirpId]; ok && !chirpDb.DeletedAt.Valid {
+			chirpsDb = append(chirpsDb, chirpDb)
+		}
+	}
+	return chirpsDb, nil
+}
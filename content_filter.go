@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/AhmettCelik/web-server/internal/auth"
+	"github.com/AhmettCelik/web-server/internal/contentfilter"
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/google/uuid"
+)
+
+type contentFilterRule struct {
+	Id        string `json:"id"`
+	CreatedAt string `json:"created_at"`
+	Term      string `json:"term"`
+	Action    string `json:"action"`
+}
+
+type chirpFlag struct {
+	Id        string `json:"id"`
+	CreatedAt string `json:"created_at"`
+	ChirpId   string `json:"chirp_id"`
+	Term      string `json:"term"`
+}
+
+// authenticateAdmin checks the ApiKey in the Authorization header against
+// ADMIN_KEY. Admin endpoints are disabled when no key is configured.
+func (cfg *apiConfig) authenticateAdmin(req *http.Request) error {
+	if cfg.adminApiKey == "" {
+		return errors.New("admin api key is not configured")
+	}
+
+	apiKey, err := auth.GetAPIKey(req.Header)
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(apiKey), []byte(cfg.adminApiKey)) != 1 {
+		return errors.New("invalid admin api key")
+	}
+
+	return nil
+}
+
+// reloadContentFilter rebuilds the content filter from the configured word
+// list followed by the rules in the database, which take precedence. The
+// old filter stays in use if anything fails.
+func (cfg *apiConfig) reloadContentFilter(ctx context.Context) error {
+	rules := contentfilter.DefaultRules()
+	if cfg.filterFile != "" {
+		fileRules, err := contentfilter.LoadRules(cfg.filterFile)
+		if err != nil {
+			return err
+		}
+		rules = fileRules
+	}
+
+	dbRules, err := cfg.db.GetContentFilterRules(ctx)
+	if err != nil {
+		return err
+	}
+	for _, rule := range dbRules {
+		// Rules saved before phrases were rejected would stop the
+		// whole filter from loading.
+		if !contentfilter.SingleWord(rule.Term) {
+			log.Printf("Skipping content filter rule %q: not a single word", rule.Term)
+			continue
+		}
+		rules = append(rules, contentfilter.Rule{Term: rule.Term, Action: rule.Action})
+	}
+
+	filter, err := contentfilter.New(rules)
+	if err != nil {
+		return err
+	}
+
+	cfg.contentFilter.Store(filter)
+	return nil
+}
+
+// saveChirpFlags records the terms that flagged a chirp for review.
+func saveChirpFlags(ctx context.Context, q *database.Queries, chirpId uuid.UUID, terms []string) error {
+	for _, term := range terms {
+		if err := q.CreateChirpFlag(ctx, database.CreateChirpFlagParams{
+			ChirpID: chirpId,
+			Term:    term,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) getContentFilterRules(w http.ResponseWriter, req *http.Request) {
+	if err := cfg.authenticateAdmin(req); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid api key")
+		return
+	}
+
+	rulesDb, err := cfg.db.GetContentFilterRules(context.Background())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting rules")
+		log.Printf("Error getting content filter rules: %v", err)
+		return
+	}
+
+	rulesJson := []contentFilterRule{}
+	for _, rule := range rulesDb {
+		rulesJson = append(rulesJson, contentFilterRule{
+			Id:        rule.ID.String(),
+			CreatedAt: rule.CreatedAt.String(),
+			Term:      rule.Term,
+			Action:    rule.Action,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, rulesJson)
+}
+
+// upsertContentFilterRule adds a rule, or changes the action of the rule for
+// the same term, and reloads the filter so it applies right away.
+func (cfg *apiConfig) upsertContentFilterRule(w http.ResponseWriter, req *http.Request) {
+	if err := cfg.authenticateAdmin(req); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid api key")
+		return
+	}
+
+	rule := contentfilter.Rule{}
+	if err := json.NewDecoder(req.Body).Decode(&rule); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	term := contentfilter.Normalize(strings.TrimSpace(rule.Term))
+	if term == "" || !contentfilter.ValidAction(rule.Action) {
+		respondWithError(w, http.StatusBadRequest, "A rule needs a term and an action of mask, reject or flag")
+		return
+	}
+	if !contentfilter.SingleWord(term) {
+		respondWithError(w, http.StatusBadRequest, "Terms must be a single word")
+		return
+	}
+
+	ruleDb, err := cfg.db.UpsertContentFilterRule(context.Background(), database.UpsertContentFilterRuleParams{
+		Term:   term,
+		Action: rule.Action,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error saving rule")
+		log.Printf("Error saving content filter rule: %v", err)
+		return
+	}
+
+	if err := cfg.reloadContentFilter(context.Background()); err != nil {
+		log.Printf("Error reloading content filter: %v", err)
+	}
+
+	respondWithJSON(w, http.StatusOK, contentFilterRule{
+		Id:        ruleDb.ID.String(),
+		CreatedAt: ruleDb.CreatedAt.String(),
+		Term:      ruleDb.Term,
+		Action:    ruleDb.Action,
+	})
+}
+
+func (cfg *apiConfig) deleteContentFilterRule(w http.ResponseWriter, req *http.Request) {
+	if err := cfg.authenticateAdmin(req); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid api key")
+		return
+	}
+
+	ruleId, err := uuid.Parse(req.PathValue("ruleID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	deleted, err := cfg.db.DeleteContentFilterRule(context.Background(), ruleId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting rule")
+		log.Printf("Error deleting content filter rule: %v", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Rule not found")
+		return
+	}
+
+	if err := cfg.reloadContentFilter(context.Background()); err != nil {
+		log.Printf("Error reloading content filter: %v", err)
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// reloadContentFilterHandler picks up changes to the word list file or the
+// database without restarting the server.
+func (cfg *apiConfig) reloadContentFilterHandler(w http.ResponseWriter, req *http.Request) {
+	if err := cfg.authenticateAdmin(req); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid api key")
+		return
+	}
+
+	if err := cfg.reloadContentFilter(context.Background()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error reloading content filter")
+		log.Printf("Error reloading content filter: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) getChirpFlags(w http.ResponseWriter, req *http.Request) {
+	if err := cfg.authenticateAdmin(req); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid api key")
+		return
+	}
+
+	limit := 100
+	if value := req.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = min(parsed, 1000)
+	}
+
+	flagsDb, err := cfg.db.GetChirpFlags(context.Background(), int32(limit))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting flags")
+		log.Printf("Error getting chirp flags: %v", err)
+		return
+	}
+
+	flagsJson := []chirpFlag{}
+	for _, flag := range flagsDb {
+		flagsJson = append(flagsJson, chirpFlag{
+			Id:        flag.ID.String(),
+			CreatedAt: flag.CreatedAt.String(),
+			ChirpId:   flag.ChirpID.String(),
+			Term:      flag.Term,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, flagsJson)
+}
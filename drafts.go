@@ -0,0 +1,425 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/google/uuid"
+)
+
+type draft struct {
+	Id        string `json:"id"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	draftInput
+	ValidationError string `json:"validation_error,omitempty"`
+}
+
+// draftInput is what clients send when saving a draft: any of the fields a
+// new chirp takes. Nothing is validated beyond the ids being well formed,
+// since drafts are allowed to be unfinished.
+type draftInput struct {
+	Body              string     `json:"body"`
+	Kind              string     `json:"kind"`
+	ReferencedChirpID string     `json:"referenced_chirp_id,omitempty"`
+	InReplyToID       string     `json:"in_reply_to_id,omitempty"`
+	MediaIDs          []string   `json:"media_ids"`
+	PublishAt         *time.Time `json:"publish_at,omitempty"`
+	Visibility        string     `json:"visibility"`
+	ContentWarning    string     `json:"content_warning,omitempty"`
+	Sensitive         bool       `json:"sensitive"`
+	Poll              *pollInput `json:"poll,omitempty"`
+}
+
+// draftParams is a draftInput with its ids parsed, in the form it is
+// stored.
+type draftParams struct {
+	referencedChirpID uuid.NullUUID
+	inReplyToID       uuid.NullUUID
+	mediaIds          []uuid.UUID
+	publishAt         sql.NullTime
+	visibility        string
+	pollOptions       []string
+	pollClosesAt      sql.NullTime
+}
+
+func parseNullUUID(s string) (uuid.NullUUID, error) {
+	if s == "" {
+		return uuid.NullUUID{}, nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
+
+func (input draftInput) parse() (draftParams, error) {
+	var params draftParams
+	var err error
+
+	params.referencedChirpID, err = parseNullUUID(input.ReferencedChirpID)
+	if err != nil {
+		return draftParams{}, err
+	}
+	params.inReplyToID, err = parseNullUUID(input.InReplyToID)
+	if err != nil {
+		return draftParams{}, err
+	}
+
+	params.mediaIds = []uuid.UUID{}
+	for _, id := range input.MediaIDs {
+		mediaId, err := uuid.Parse(id)
+		if err != nil {
+			return draftParams{}, err
+		}
+		params.mediaIds = append(params.mediaIds, mediaId)
+	}
+
+	if input.PublishAt != nil {
+		params.publishAt = sql.NullTime{Time: input.PublishAt.Local(), Valid: true}
+	}
+
+	params.visibility = input.Visibility
+	if params.visibility == "" {
+		params.visibility = chirpVisibilityPublic
+	}
+
+	params.pollOptions = []string{}
+	if input.Poll != nil {
+		params.pollOptions = append(params.pollOptions, input.Poll.Options...)
+		if input.Poll.ClosesAt != nil {
+			params.pollClosesAt = sql.NullTime{Time: input.Poll.ClosesAt.Local(), Valid: true}
+		}
+	}
+
+	return params, nil
+}
+
+// draftFromDb turns a stored draft back into what the client saved.
+func draftFromDb(draftDb database.Draft) draftInput {
+	input := draftInput{
+		Body:           draftDb.Body,
+		Kind:           draftDb.Kind,
+		MediaIDs:       []string{},
+		Visibility:     draftDb.Visibility,
+		ContentWarning: draftDb.ContentWarning,
+		Sensitive:      draftDb.Sensitive,
+	}
+	if draftDb.ReferencedChirpID.Valid {
+		input.ReferencedChirpID = draftDb.ReferencedChirpID.UUID.String()
+	}
+	if draftDb.InReplyToID.Valid {
+		input.InReplyToID = draftDb.InReplyToID.UUID.String()
+	}
+	for _, id := range draftDb.MediaIds {
+		input.MediaIDs = append(input.MediaIDs, id.String())
+	}
+	if draftDb.PublishAt.Valid {
+		input.PublishAt = &draftDb.PublishAt.Time
+	}
+	if draftDb.HasPoll {
+		input.Poll = &pollInput{Options: draftDb.PollOptions}
+		if draftDb.PollClosesAt.Valid {
+			input.Poll.ClosesAt = &draftDb.PollClosesAt.Time
+		}
+	}
+	return input
+}
+
+// draftPost turns a draft into the payload validatePost would receive.
+func draftPost(draftDb database.Draft) interpreter {
+	input := draftFromDb(draftDb)
+	return interpreter{
+		Body:              input.Body,
+		Kind:              input.Kind,
+		ReferencedChirpID: input.ReferencedChirpID,
+		InReplyToID:       input.InReplyToID,
+		MediaIDs:          input.MediaIDs,
+		PublishAt:         input.PublishAt,
+		Visibility:        input.Visibility,
+		ContentWarning:    input.ContentWarning,
+		Sensitive:         input.Sensitive,
+		Poll:              input.Poll,
+	}
+}
+
+func draftResponse(draftDb database.Draft) draft {
+	return draft{
+		Id:         draftDb.ID.String(),
+		CreatedAt:  draftDb.CreatedAt.String(),
+		UpdatedAt:  draftDb.UpdatedAt.String(),
+		draftInput: draftFromDb(draftDb),
+	}
+}
+
+// validatedDraftResponse converts a draft to JSON and reports the first
+// problem that would stop it from being published. Lists of drafts leave
+// this out, since checking means several queries per draft.
+func (cfg *apiConfig) validatedDraftResponse(ctx context.Context, draftDb database.Draft) draft {
+	draftJson := draftResponse(draftDb)
+
+	_, err := cfg.prepareChirp(ctx, draftDb.UserID, draftPost(draftDb))
+	var chirpErr *chirpError
+	if errors.As(err, &chirpErr) {
+		draftJson.ValidationError = chirpErr.msg
+	} else if err != nil {
+		log.Printf("Error validating draft: %v", err)
+	}
+
+	return draftJson
+}
+
+// getOwnDraft loads a draft from the path, answering with an error itself
+// when the draft can not be used.
+func (cfg *apiConfig) getOwnDraft(w http.ResponseWriter, req *http.Request, userId uuid.UUID) (database.Draft, bool) {
+	draftId, err := uuid.Parse(req.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return database.Draft{}, false
+	}
+
+	draftDb, err := cfg.db.GetDraft(context.Background(), database.GetDraftParams{
+		ID:     draftId,
+		UserID: userId,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Draft not found")
+		return database.Draft{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting draft")
+		log.Printf("Error getting draft: %v", err)
+		return database.Draft{}, false
+	}
+
+	return draftDb, true
+}
+
+func (cfg *apiConfig) createDraft(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating: %v", err)
+		return
+	}
+
+	input := draftInput{}
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	params, err := input.parse()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	draftDb, err := cfg.db.CreateDraft(context.Background(), database.CreateDraftParams{
+		UserID:            userId,
+		Body:              input.Body,
+		Kind:              input.Kind,
+		ReferencedChirpID: params.referencedChirpID,
+		MediaIds:          params.mediaIds,
+		InReplyToID:       params.inReplyToID,
+		PublishAt:         params.publishAt,
+		Visibility:        params.visibility,
+		ContentWarning:    input.ContentWarning,
+		Sensitive:         input.Sensitive,
+		HasPoll:           input.Poll != nil,
+		PollOptions:       params.pollOptions,
+		PollClosesAt:      params.pollClosesAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error saving draft")
+		log.Printf("Error creating draft: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, cfg.validatedDraftResponse(context.Background(), draftDb))
+}
+
+func (cfg *apiConfig) getDrafts(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating: %v", err)
+		return
+	}
+
+	draftsDb, err := cfg.db.GetDraftsForUser(context.Background(), userId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting drafts")
+		log.Printf("Error getting drafts: %v", err)
+		return
+	}
+
+	draftsJson := []draft{}
+	for _, draftDb := range draftsDb {
+		draftsJson = append(draftsJson, draftResponse(draftDb))
+	}
+
+	respondWithJSON(w, http.StatusOK, draftsJson)
+}
+
+func (cfg *apiConfig) getDraft(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating: %v", err)
+		return
+	}
+
+	draftDb, ok := cfg.getOwnDraft(w, req, userId)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, cfg.validatedDraftResponse(context.Background(), draftDb))
+}
+
+func (cfg *apiConfig) updateDraft(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating: %v", err)
+		return
+	}
+
+	draftDb, ok := cfg.getOwnDraft(w, req, userId)
+	if !ok {
+		return
+	}
+
+	input := draftInput{}
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	params, err := input.parse()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	draftDb, err = cfg.db.UpdateDraft(context.Background(), database.UpdateDraftParams{
+		Body:              input.Body,
+		Kind:              input.Kind,
+		ReferencedChirpID: params.referencedChirpID,
+		MediaIds:          params.mediaIds,
+		InReplyToID:       params.inReplyToID,
+		PublishAt:         params.publishAt,
+		Visibility:        params.visibility,
+		ContentWarning:    input.ContentWarning,
+		Sensitive:         input.Sensitive,
+		HasPoll:           input.Poll != nil,
+		PollOptions:       params.pollOptions,
+		PollClosesAt:      params.pollClosesAt,
+		ID:                draftDb.ID,
+		UserID:            userId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error saving draft")
+		log.Printf("Error updating draft: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, cfg.validatedDraftResponse(context.Background(), draftDb))
+}
+
+func (cfg *apiConfig) deleteDraft(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating: %v", err)
+		return
+	}
+
+	draftDb, ok := cfg.getOwnDraft(w, req, userId)
+	if !ok {
+		return
+	}
+
+	if _, err := cfg.db.DeleteDraft(context.Background(), database.DeleteDraftParams{
+		ID:     draftDb.ID,
+		UserID: userId,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting draft")
+		log.Printf("Error deleting draft: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// publishDraft runs a draft through the same pipeline as validatePost. If it
+// does not pass, the draft is kept and returned with the problem instead.
+func (cfg *apiConfig) publishDraft(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating: %v", err)
+		return
+	}
+
+	draftDb, ok := cfg.getOwnDraft(w, req, userId)
+	if !ok {
+		return
+	}
+
+	prepared, err := cfg.prepareChirp(context.Background(), userId, draftPost(draftDb))
+	var chirpErr *chirpError
+	if errors.As(err, &chirpErr) {
+		draftJson := draftResponse(draftDb)
+		draftJson.ValidationError = chirpErr.msg
+		respondWithJSON(w, http.StatusUnprocessableEntity, draftJson)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error validating draft")
+		log.Printf("Error validating draft: %v", err)
+		return
+	}
+
+	var chirpDb database.Chirp
+	err = cfg.withTx(context.Background(), func(q *database.Queries) error {
+		chirpDb, err = insertChirp(context.Background(), q, prepared)
+		if err != nil {
+			return err
+		}
+		_, err = q.DeleteDraft(context.Background(), database.DeleteDraftParams{
+			ID:     draftDb.ID,
+			UserID: userId,
+		})
+		return err
+	})
+	if errors.As(err, &chirpErr) {
+		draftJson := draftResponse(draftDb)
+		draftJson.ValidationError = chirpErr.msg
+		respondWithJSON(w, http.StatusUnprocessableEntity, draftJson)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error publishing draft")
+		log.Printf("Error publishing draft: %v", err)
+		return
+	}
+	cfg.streamChirpsCreated(context.Background(), []database.Chirp{chirpDb})
+
+	chirpJson, err := cfg.chirpResponse(context.Background(), userId, chirpDb)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading chirp details")
+		log.Printf("Error loading chirp details: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, chirpJson)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/google/uuid"
+)
+
+// followEntry is one user in a followers or following list.
+type followEntry struct {
+	UserId         string `json:"user_id"`
+	FollowedAt     string `json:"followed_at"`
+	FollowerCount  int64  `json:"follower_count"`
+	FollowingCount int64  `json:"following_count"`
+}
+
+// followCounts loads follower and following counts for users, keyed by user
+// id. Users missing from the result have no follows either way.
+func (cfg *apiConfig) followCounts(ctx context.Context, userIds []uuid.UUID) (map[uuid.UUID]database.GetFollowCountsRow, error) {
+	counts := map[uuid.UUID]database.GetFollowCountsRow{}
+
+	countsDb, err := cfg.db.GetFollowCounts(ctx, userIds)
+	if err != nil {
+		return counts, err
+	}
+	for _, count := range countsDb {
+		counts[count.ID] = count
+	}
+	return counts, nil
+}
+
+func (cfg *apiConfig) followUser(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating follow: %v", err)
+		return
+	}
+
+	followeeId, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	// The follows table rejects self-follows and duplicates itself, so two
+	// requests racing each other can't get around the checks.
+	err = cfg.withTx(context.Background(), func(q *database.Queries) error {
+		return startFollowing(context.Background(), q, userId, followeeId)
+	})
+	if isCheckViolation(err) {
+		respondWithError(w, http.StatusBadRequest, "You can not follow yourself")
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Already following")
+		return
+	}
+	if errors.Is(err, errFollowBlocked) {
+		respondWithError(w, http.StatusForbidden, "You can not follow this user")
+		return
+	}
+	if isForeignKeyViolation(err) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error following user")
+		log.Printf("Error creating follow: %v", err)
+		return
+	}
+
+	cfg.liveFollowChanged(userId, followeeId, true)
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) unfollowUser(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating unfollow: %v", err)
+		return
+	}
+
+	followeeId, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	var deleted bool
+	err = cfg.withTx(context.Background(), func(q *database.Queries) error {
+		var err error
+		deleted, err = stopFollowing(context.Background(), q, userId, followeeId)
+		return err
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error unfollowing user")
+		log.Printf("Error deleting follow: %v", err)
+		return
+	}
+	if !deleted {
+		respondWithError(w, http.StatusNotFound, "Not following")
+		return
+	}
+
+	cfg.liveFollowChanged(userId, followeeId, false)
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// userLink is one row of a relationship between users, such as a follow or
+// a block: the other user and when the relationship was made.
+type userLink struct {
+	userId    uuid.UUID
+	createdAt time.Time
+}
+
+func (cfg *apiConfig) getFollowers(w http.ResponseWriter, req *http.Request) {
+	cfg.listFollows(w, req, func(ctx context.Context, params database.GetFollowersParams) ([]userLink, error) {
+		rows, err := cfg.db.GetFollowers(ctx, params)
+		var links []userLink
+		for _, row := range rows {
+			links = append(links, userLink{userId: row.UserID, createdAt: row.CreatedAt})
+		}
+		return links, err
+	})
+}
+
+func (cfg *apiConfig) getFollowing(w http.ResponseWriter, req *http.Request) {
+	cfg.listFollows(w, req, func(ctx context.Context, params database.GetFollowersParams) ([]userLink, error) {
+		rows, err := cfg.db.GetFollowing(ctx, database.GetFollowingParams(params))
+		var links []userLink
+		for _, row := range rows {
+			links = append(links, userLink{userId: row.UserID, createdAt: row.CreatedAt})
+		}
+		return links, err
+	})
+}
+
+// listFollows answers with one page of a user's followers or following,
+// newest follows first.
+func (cfg *apiConfig) listFollows(w http.ResponseWriter, req *http.Request, load func(context.Context, database.GetFollowersParams) ([]userLink, error)) {
+	userId, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	limit, cursor, err := pageParams(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	links, err := load(context.Background(), database.GetFollowersParams{
+		UserID:          userId,
+		BeforeCreatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		PageSize:        limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting follows")
+		log.Printf("Error getting follows: %v", err)
+		return
+	}
+
+	var userIds []uuid.UUID
+	for _, link := range links {
+		userIds = append(userIds, link.userId)
+	}
+
+	counts, err := cfg.followCounts(context.Background(), userIds)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting follows")
+		log.Printf("Error getting follow counts: %v", err)
+		return
+	}
+
+	follows := page[followEntry]{Items: []followEntry{}}
+	for _, link := range links {
+		follows.Items = append(follows.Items, followEntry{
+			UserId:         link.userId.String(),
+			FollowedAt:     link.createdAt.String(),
+			FollowerCount:  counts[link.userId].FollowerCount,
+			FollowingCount: counts[link.userId].FollowingCount,
+		})
+	}
+	if len(links) == int(limit) {
+		last := links[len(links)-1]
+		follows.NextCursor = pageCursor{CreatedAt: last.createdAt, ID: last.userId}.String()
+	}
+
+	respondWithJSON(w, http.StatusOK, follows)
+}
@@ -34,6 +34,13 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 }
 
 func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+	userID, _, err := ValidateJWTWithExpiry(tokenString, tokenSecret)
+	return userID, err
+}
+
+// ValidateJWTWithExpiry validates the token like ValidateJWT and also returns
+// when it expires, or the zero time if it never does.
+func ValidateJWTWithExpiry(tokenString, tokenSecret string) (uuid.UUID, time.Time, error) {
 	claims := &jwt.RegisteredClaims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
@@ -41,19 +48,24 @@ func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
 	})
 
 	if err != nil || !token.Valid {
-		return uuid.Nil, fmt.Errorf("invalid token: %w", err)
+		return uuid.Nil, time.Time{}, fmt.Errorf("invalid token: %w", err)
 	}
 
 	if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(time.Now()) {
-		return uuid.Nil, fmt.Errorf("This token has expired")
+		return uuid.Nil, time.Time{}, fmt.Errorf("This token has expired")
 	}
 
 	userID, err := uuid.Parse(claims.Subject)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid user ID in token: %w", err)
+		return uuid.Nil, time.Time{}, fmt.Errorf("invalid user ID in token: %w", err)
+	}
+
+	var expiresAt time.Time
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
 	}
 
-	return userID, nil
+	return userID, expiresAt, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
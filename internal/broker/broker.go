@@ -0,0 +1,130 @@
+// Package broker fans events out to live subscribers and keeps a bounded
+// buffer of recent events so subscribers that reconnect can catch up.
+package broker
+
+import "sync"
+
+// Event is a published value with the id it was given. Ids increase by one
+// with every event, starting at 1 when the broker is created.
+type Event[T any] struct {
+	ID    uint64
+	Value T
+}
+
+// Broker delivers events to subscribers without ever blocking the publisher.
+// A subscriber that falls too far behind is dropped and its channel closed;
+// it can subscribe again from the last event it saw.
+type Broker[T any] struct {
+	mu     sync.Mutex
+	lastID uint64
+	replay []Event[T]
+	size   int
+	subs   map[*Subscription[T]]struct{}
+}
+
+// Subscription receives the events matching its filter.
+type Subscription[T any] struct {
+	broker *Broker[T]
+	events chan Event[T]
+	match  func(T) bool
+	closed bool
+}
+
+// New creates a broker that keeps the last replaySize events for replay.
+func New[T any](replaySize int) *Broker[T] {
+	return &Broker[T]{
+		size: replaySize,
+		subs: map[*Subscription[T]]struct{}{},
+	}
+}
+
+// Publish assigns the next id to value and sends it to every matching
+// subscriber.
+func (b *Broker[T]) Publish(value T) Event[T] {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	ev := Event[T]{ID: b.lastID, Value: value}
+
+	b.replay = append(b.replay, ev)
+	if len(b.replay) > b.size {
+		b.replay = b.replay[len(b.replay)-b.size:]
+	}
+
+	for sub := range b.subs {
+		if !sub.match(value) {
+			continue
+		}
+		select {
+		case sub.events <- ev:
+		default:
+			b.remove(sub)
+		}
+	}
+
+	return ev
+}
+
+// Subscribe starts delivering events matching match to a channel buffered
+// for buffer events. With afterID set, buffered events after it are replayed
+// first. The returned bool is false when afterID can't be resumed from,
+// because the events after it are no longer buffered or the id was never
+// issued by this broker; the subscriber should then reload its state.
+func (b *Broker[T]) Subscribe(afterID uint64, buffer int, match func(T) bool) (*Subscription[T], bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &Subscription[T]{
+		broker: b,
+		match:  match,
+	}
+
+	resumed := true
+	var missed []Event[T]
+	if afterID > 0 {
+		oldest := b.lastID + 1
+		if len(b.replay) > 0 {
+			oldest = b.replay[0].ID
+		}
+		if afterID > b.lastID || afterID+1 < oldest {
+			resumed = false
+		} else {
+			for _, ev := range b.replay {
+				if ev.ID > afterID && match(ev.Value) {
+					missed = append(missed, ev)
+				}
+			}
+		}
+	}
+
+	sub.events = make(chan Event[T], buffer+len(missed))
+	for _, ev := range missed {
+		sub.events <- ev
+	}
+
+	b.subs[sub] = struct{}{}
+	return sub, resumed
+}
+
+// Events is closed when the subscription is closed or dropped for falling
+// behind.
+func (s *Subscription[T]) Events() <-chan Event[T] {
+	return s.events
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription[T]) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+func (b *Broker[T]) remove(sub *Subscription[T]) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(b.subs, sub)
+	close(sub.events)
+}
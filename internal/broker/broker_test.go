@@ -0,0 +1,80 @@
+package broker
+
+import "testing"
+
+func collect(sub *Subscription[int]) []int {
+	var values []int
+	for {
+		select {
+		case ev, ok := <-sub.Events():
+			if !ok {
+				return values
+			}
+			values = append(values, ev.Value)
+		default:
+			return values
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	even := func(v int) bool { return v%2 == 0 }
+
+	cases := []struct {
+		name     string
+		afterID  uint64
+		resumed  bool
+		expected []int
+	}{
+		{name: "live only", afterID: 0, resumed: true, expected: []int{6, 8}},
+		{name: "resume from buffer", afterID: 4, resumed: true, expected: []int{6, 6, 8}},
+		{name: "resume from latest", afterID: 6, resumed: true, expected: []int{6, 8}},
+		{name: "too old to resume", afterID: 1, resumed: false, expected: []int{6, 8}},
+		{name: "unknown id", afterID: 99, resumed: false, expected: []int{6, 8}},
+	}
+
+	for _, c := range cases {
+		// Each event's id matches its value, and the buffer keeps 4 to 6.
+		b := New[int](3)
+		for v := 1; v <= 6; v++ {
+			b.Publish(v)
+		}
+
+		sub, resumed := b.Subscribe(c.afterID, 10, even)
+		if resumed != c.resumed {
+			t.Errorf("%s: resumed = %v, expected %v", c.name, resumed, c.resumed)
+		}
+		b.Publish(6)
+		b.Publish(7)
+		b.Publish(8)
+
+		actual := collect(sub)
+		if len(actual) != len(c.expected) {
+			t.Errorf("%s: got %v, expected %v", c.name, actual, c.expected)
+			continue
+		}
+		for i := range actual {
+			if actual[i] != c.expected[i] {
+				t.Errorf("%s: got %v, expected %v", c.name, actual, c.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	b := New[int](10)
+	sub, _ := b.Subscribe(0, 1, func(int) bool { return true })
+
+	b.Publish(1)
+	b.Publish(2)
+
+	if actual := collect(sub); len(actual) != 1 || actual[0] != 1 {
+		t.Errorf("got %v, expected [1] and a closed channel", actual)
+	}
+	if _, ok := <-sub.Events(); ok {
+		t.Errorf("expected the subscription to be closed")
+	}
+
+	sub.Close()
+}
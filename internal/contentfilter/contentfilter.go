@@ -0,0 +1,169 @@
+package contentfilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	ActionMask   = "mask"
+	ActionReject = "reject"
+	ActionFlag   = "flag"
+)
+
+const mask = "****"
+
+// Rule tells the filter what to do when a word matches Term.
+type Rule struct {
+	Term   string `json:"term"`
+	Action string `json:"action"`
+}
+
+// Filter matches the words of a text against a set of rules. It is safe for
+// concurrent use and never changes after it is built; reloading rules means
+// building a new Filter.
+type Filter struct {
+	actions map[string]string
+}
+
+// Result is what applying a filter to a text produced. Text has every masked
+// word replaced, Rejected is set when any reject rule matched, and Flagged
+// lists the terms of flag rules that matched.
+type Result struct {
+	Text     string
+	Rejected bool
+	Flagged  []string
+}
+
+// DefaultRules are the words chirps were always masked for, used when no
+// word list is configured.
+func DefaultRules() []Rule {
+	return []Rule{
+		{Term: "kerfuffle", Action: ActionMask},
+		{Term: "sharbert", Action: ActionMask},
+		{Term: "fornax", Action: ActionMask},
+	}
+}
+
+// LoadRules reads a JSON array of rules from path.
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return rules, nil
+}
+
+// ValidAction reports whether action is one a rule can have.
+func ValidAction(action string) bool {
+	switch action {
+	case ActionMask, ActionReject, ActionFlag:
+		return true
+	}
+	return false
+}
+
+// SingleWord reports whether term is one word as Apply splits text. Terms
+// with spaces or punctuation in them could never match.
+func SingleWord(term string) bool {
+	if term == "" {
+		return false
+	}
+	for _, r := range term {
+		if !isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// New builds a filter from rules. When several rules share a term the last
+// one wins, so later sources can override earlier ones.
+func New(rules []Rule) (*Filter, error) {
+	f := &Filter{actions: map[string]string{}}
+	for _, rule := range rules {
+		if !ValidAction(rule.Action) {
+			return nil, fmt.Errorf("invalid action %q for term %q", rule.Action, rule.Term)
+		}
+		term := Normalize(rule.Term)
+		if term == "" {
+			return nil, fmt.Errorf("empty term")
+		}
+		if !SingleWord(term) {
+			return nil, fmt.Errorf("term %q is not a single word", rule.Term)
+		}
+		f.actions[term] = rule.Action
+	}
+	return f, nil
+}
+
+// Normalize folds text into the form terms are compared in: compatibility
+// characters are decomposed, accents are dropped and case is folded, so
+// "Ｋérfuffle" and "kerfuffle" compare equal.
+func Normalize(text string) string {
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFKC)
+	normalized, _, err := transform.String(t, text)
+	if err != nil {
+		normalized = text
+	}
+	return cases.Fold().String(normalized)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// Apply runs the filter over text. Words are runs of letters, digits and
+// combining marks, so punctuation next to a word does not hide it.
+func (f *Filter) Apply(text string) Result {
+	var out strings.Builder
+	result := Result{}
+
+	flush := func(word string) {
+		switch f.actions[Normalize(word)] {
+		case ActionMask:
+			out.WriteString(mask)
+			return
+		case ActionReject:
+			result.Rejected = true
+		case ActionFlag:
+			result.Flagged = append(result.Flagged, Normalize(word))
+		}
+		out.WriteString(word)
+	}
+
+	start := -1
+	for i, r := range text {
+		inWord := isWordRune(r)
+		if inWord && start < 0 {
+			start = i
+		}
+		if !inWord {
+			if start >= 0 {
+				flush(text[start:i])
+				start = -1
+			}
+			out.WriteRune(r)
+		}
+	}
+	if start >= 0 {
+		flush(text[start:])
+	}
+
+	result.Text = out.String()
+	return result
+}
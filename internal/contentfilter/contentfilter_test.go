@@ -0,0 +1,69 @@
+package contentfilter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	filter, err := New(append(DefaultRules(),
+		Rule{Term: "spam", Action: ActionReject},
+		Rule{Term: "Suspicious", Action: ActionFlag},
+	))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	cases := []struct {
+		input    string
+		expected Result
+	}{
+		{
+			input:    "What a Kerfuffle! Such a kerfuffle, really.",
+			expected: Result{Text: "What a ****! Such a ****, really."},
+		},
+		{
+			input:    "ＦＯＲＮＡＸ and shárbert",
+			expected: Result{Text: "**** and ****"},
+		},
+		{
+			input:    "buy my SPAM now",
+			expected: Result{Text: "buy my SPAM now", Rejected: true},
+		},
+		{
+			input:    "this is suspicious...",
+			expected: Result{Text: "this is suspicious...", Flagged: []string{"suspicious"}},
+		},
+		{
+			input:    "Merhaba dünya, kerfufflers are fine",
+			expected: Result{Text: "Merhaba dünya, kerfufflers are fine"},
+		},
+	}
+
+	for _, c := range cases {
+		actual := filter.Apply(c.input)
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("Apply(%q) = %+v, expected %+v", c.input, actual, c.expected)
+		}
+	}
+}
+
+func TestNewRejectsPhrases(t *testing.T) {
+	cases := []struct {
+		term  string
+		valid bool
+	}{
+		{term: "kerfuffle", valid: true},
+		{term: "Ｋérfuffle", valid: true},
+		{term: "buy now", valid: false},
+		{term: "spam!", valid: false},
+		{term: "  ", valid: false},
+	}
+
+	for _, c := range cases {
+		_, err := New([]Rule{{Term: c.term, Action: ActionFlag}})
+		if (err == nil) != c.valid {
+			t.Errorf("New(%q) error = %v, expected valid %v", c.term, err, c.valid)
+		}
+	}
+}
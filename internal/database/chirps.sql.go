@@ -7,29 +7,61 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, kind, referenced_chirp_id, scheduled, publish_at, visibility, content_warning, sensitive, in_reply_to_id)
 VALUES (
     gen_random_uuid(),
+    COALESCE($1::timestamp, NOW()),
     NOW(),
-    NOW(),
-    $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, kind, referenced_chirp_id, deleted_at, scheduled, publish_at, visibility, content_warning, sensitive, in_reply_to_id
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	CreatedAt         sql.NullTime
+	Body              string
+	UserID            uuid.UUID
+	Kind              string
+	ReferencedChirpID uuid.NullUUID
+	Scheduled         bool
+	PublishAt         sql.NullTime
+	Visibility        string
+	ContentWarning    string
+	Sensitive         bool
+	InReplyToID       uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.CreatedAt,
+		arg.Body,
+		arg.UserID,
+		arg.Kind,
+		arg.ReferencedChirpID,
+		arg.Scheduled,
+		arg.PublishAt,
+		arg.Visibility,
+		arg.ContentWarning,
+		arg.Sensitive,
+		arg.InReplyToID,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,31 +69,61 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.Kind,
+		&i.ReferencedChirpID,
+		&i.DeletedAt,
+		&i.Scheduled,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.InReplyToID,
 	)
 	return i, err
 }
 
-const deleteChirpById = `-- name: DeleteChirpById :exec
+const deleteScheduledChirp = `-- name: DeleteScheduledChirp :execrows
 DELETE FROM chirps
-WHERE chirps.id=$1 AND chirps.user_id=$2
+WHERE id = $1 AND user_id = $2 AND scheduled
 `
 
-type DeleteChirpByIdParams struct {
+type DeleteScheduledChirpParams struct {
 	ID     uuid.UUID
 	UserID uuid.UUID
 }
 
-func (q *Queries) DeleteChirpById(ctx context.Context, arg DeleteChirpByIdParams) error {
-	_, err := q.db.ExecContext(ctx, deleteChirpById, arg.ID, arg.UserID)
-	return err
+func (q *Queries) DeleteScheduledChirp(ctx context.Context, arg DeleteScheduledChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteScheduledChirp, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getChirpById = `-- name: GetChirpById :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE id=$1
+SELECT id, created_at, updated_at, body, user_id, kind, referenced_chirp_id, deleted_at, scheduled, publish_at, visibility, content_warning, sensitive, in_reply_to_id FROM chirps
+WHERE id = $1 AND deleted_at IS NULL AND NOT scheduled
+AND (
+    visibility = 'public' OR user_id = $2
+    OR (visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = $2 AND follows.followee_id = chirps.user_id
+    ))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2
+    OR blocks.blocker_id = $2 AND blocks.blocked_id = chirps.user_id
+)
 `
 
-func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, getChirpById, id)
+type GetChirpByIdParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.UUID
+}
+
+func (q *Queries) GetChirpById(ctx context.Context, arg GetChirpByIdParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpById, arg.ID, arg.ViewerID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -69,16 +131,103 @@ func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.Kind,
+		&i.ReferencedChirpID,
+		&i.DeletedAt,
+		&i.Scheduled,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.InReplyToID,
 	)
 	return i, err
 }
 
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps ORDER BY created_at
+SELECT id, created_at, updated_at, body, user_id, kind, referenced_chirp_id, deleted_at, scheduled, publish_at, visibility, content_warning, sensitive, in_reply_to_id FROM chirps
+WHERE deleted_at IS NULL AND NOT scheduled
+AND (
+    visibility = 'public' OR user_id = $1
+    OR (visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = $1 AND follows.followee_id = chirps.user_id
+    ))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $1
+    OR blocks.blocker_id = $1 AND blocks.blocked_id = chirps.user_id
+)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = $1 AND mutes.muted_id = chirps.user_id
+)
+ORDER BY created_at
+`
+
+func (q *Queries) GetChirps(ctx context.Context, viewerID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirps, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Kind,
+			&i.ReferencedChirpID,
+			&i.DeletedAt,
+			&i.Scheduled,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.InReplyToID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIds = `-- name: GetChirpsByIds :many
+SELECT id, created_at, updated_at, body, user_id, kind, referenced_chirp_id, deleted_at, scheduled, publish_at, visibility, content_warning, sensitive, in_reply_to_id FROM chirps
+WHERE id = ANY($1::uuid[]) AND NOT scheduled
+AND (
+    visibility = 'public' OR user_id = $2
+    OR (visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = $2 AND follows.followee_id = chirps.user_id
+    ))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2
+    OR blocks.blocker_id = $2 AND blocks.blocked_id = chirps.user_id
+)
 `
 
-func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps)
+type GetChirpsByIdsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.UUID
+}
+
+func (q *Queries) GetChirpsByIds(ctx context.Context, arg GetChirpsByIdsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIds, pq.Array(arg.Ids), arg.ViewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -92,6 +241,15 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.Kind,
+			&i.ReferencedChirpID,
+			&i.DeletedAt,
+			&i.Scheduled,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.InReplyToID,
 		); err != nil {
 			return nil, err
 		}
@@ -107,11 +265,180 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpsForUserID = `-- name: GetChirpsForUserID :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE user_id=$1 ORDER BY created_at
+SELECT id, created_at, updated_at, body, user_id, kind, referenced_chirp_id, deleted_at, scheduled, publish_at, visibility, content_warning, sensitive, in_reply_to_id FROM chirps
+WHERE user_id = $1 AND deleted_at IS NULL AND NOT scheduled
+AND (
+    visibility = 'public' OR user_id = $2
+    OR (visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = $2 AND follows.followee_id = chirps.user_id
+    ))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2
+    OR blocks.blocker_id = $2 AND blocks.blocked_id = chirps.user_id
+)
+ORDER BY created_at
+`
+
+type GetChirpsForUserIDParams struct {
+	UserID   uuid.UUID
+	ViewerID uuid.UUID
+}
+
+func (q *Queries) GetChirpsForUserID(ctx context.Context, arg GetChirpsForUserIDParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsForUserID, arg.UserID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Kind,
+			&i.ReferencedChirpID,
+			&i.DeletedAt,
+			&i.Scheduled,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.InReplyToID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeletedChirpById = `-- name: GetDeletedChirpById :one
+SELECT id, created_at, updated_at, body, user_id, kind, referenced_chirp_id, deleted_at, scheduled, publish_at, visibility, content_warning, sensitive, in_reply_to_id FROM chirps
+WHERE id = $1 AND deleted_at IS NOT NULL
+AND (
+    visibility = 'public' OR user_id = $2
+    OR (visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = $2 AND follows.followee_id = chirps.user_id
+    ))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2
+    OR blocks.blocker_id = $2 AND blocks.blocked_id = chirps.user_id
+)
+`
+
+type GetDeletedChirpByIdParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.UUID
+}
+
+func (q *Queries) GetDeletedChirpById(ctx context.Context, arg GetDeletedChirpByIdParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getDeletedChirpById, arg.ID, arg.ViewerID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Kind,
+		&i.ReferencedChirpID,
+		&i.DeletedAt,
+		&i.Scheduled,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.InReplyToID,
+	)
+	return i, err
+}
+
+const getDueChirpIds = `-- name: GetDueChirpIds :many
+SELECT id FROM chirps
+WHERE scheduled AND publish_at <= NOW() AND deleted_at IS NULL
+ORDER BY publish_at ASC
+LIMIT $1
+`
+
+func (q *Queries) GetDueChirpIds(ctx context.Context, limit int32) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getDueChirpIds, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getScheduledChirp = `-- name: GetScheduledChirp :one
+SELECT id, created_at, updated_at, body, user_id, kind, referenced_chirp_id, deleted_at, scheduled, publish_at, visibility, content_warning, sensitive, in_reply_to_id FROM chirps
+WHERE id=$1 AND user_id=$2 AND scheduled AND deleted_at IS NULL
+`
+
+type GetScheduledChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetScheduledChirp(ctx context.Context, arg GetScheduledChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getScheduledChirp, arg.ID, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Kind,
+		&i.ReferencedChirpID,
+		&i.DeletedAt,
+		&i.Scheduled,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.InReplyToID,
+	)
+	return i, err
+}
+
+const getScheduledChirpsForUser = `-- name: GetScheduledChirpsForUser :many
+SELECT id, created_at, updated_at, body, user_id, kind, referenced_chirp_id, deleted_at, scheduled, publish_at, visibility, content_warning, sensitive, in_reply_to_id FROM chirps
+WHERE user_id=$1 AND scheduled AND deleted_at IS NULL
+ORDER BY publish_at
 `
 
-func (q *Queries) GetChirpsForUserID(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsForUserID, userID)
+func (q *Queries) GetScheduledChirpsForUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getScheduledChirpsForUser, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -125,6 +452,15 @@ func (q *Queries) GetChirpsForUserID(ctx context.Context, userID uuid.UUID) ([]C
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.Kind,
+			&i.ReferencedChirpID,
+			&i.DeletedAt,
+			&i.Scheduled,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.InReplyToID,
 		); err != nil {
 			return nil, err
 		}
@@ -138,3 +474,169 @@ func (q *Queries) GetChirpsForUserID(ctx context.Context, userID uuid.UUID) ([]C
 	}
 	return items, nil
 }
+
+const publishDueChirp = `-- name: PublishDueChirp :one
+UPDATE chirps SET scheduled = FALSE, created_at = publish_at, updated_at = NOW()
+WHERE id = $1 AND scheduled AND publish_at <= NOW() AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, kind, referenced_chirp_id, deleted_at, scheduled, publish_at, visibility, content_warning, sensitive, in_reply_to_id
+`
+
+func (q *Queries) PublishDueChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, publishDueChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Kind,
+		&i.ReferencedChirpID,
+		&i.DeletedAt,
+		&i.Scheduled,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.InReplyToID,
+	)
+	return i, err
+}
+
+const purgeDeletedChirps = `-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps WHERE deleted_at < $1::timestamp
+`
+
+func (q *Queries) PurgeDeletedChirps(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedChirps, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_at > $3::timestamp
+RETURNING id, created_at, updated_at, body, user_id, kind, referenced_chirp_id, deleted_at, scheduled, publish_at, visibility, content_warning, sensitive, in_reply_to_id
+`
+
+type RestoreChirpParams struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	DeletedAfter time.Time
+}
+
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, arg.ID, arg.UserID, arg.DeletedAfter)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Kind,
+		&i.ReferencedChirpID,
+		&i.DeletedAt,
+		&i.Scheduled,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.InReplyToID,
+	)
+	return i, err
+}
+
+const softDeleteChirpById = `-- name: SoftDeleteChirpById :execrows
+UPDATE chirps SET deleted_at = NOW(), updated_at = NOW()
+WHERE chirps.id=$1 AND chirps.user_id=$2 AND deleted_at IS NULL
+`
+
+type SoftDeleteChirpByIdParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) SoftDeleteChirpById(ctx context.Context, arg SoftDeleteChirpByIdParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, softDeleteChirpById, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateChirpVisibility = `-- name: UpdateChirpVisibility :one
+UPDATE chirps SET visibility = $1, updated_at = NOW()
+WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, kind, referenced_chirp_id, deleted_at, scheduled, publish_at, visibility, content_warning, sensitive, in_reply_to_id
+`
+
+type UpdateChirpVisibilityParams struct {
+	Visibility string
+	ID         uuid.UUID
+	UserID     uuid.UUID
+}
+
+func (q *Queries) UpdateChirpVisibility(ctx context.Context, arg UpdateChirpVisibilityParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpVisibility, arg.Visibility, arg.ID, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Kind,
+		&i.ReferencedChirpID,
+		&i.DeletedAt,
+		&i.Scheduled,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.InReplyToID,
+	)
+	return i, err
+}
+
+const updateScheduledChirp = `-- name: UpdateScheduledChirp :one
+UPDATE chirps SET body = $1, publish_at = $2, updated_at = NOW()
+WHERE id = $3 AND user_id = $4 AND scheduled
+RETURNING id, created_at, updated_at, body, user_id, kind, referenced_chirp_id, deleted_at, scheduled, publish_at, visibility, content_warning, sensitive, in_reply_to_id
+`
+
+type UpdateScheduledChirpParams struct {
+	Body      string
+	PublishAt sql.NullTime
+	ID        uuid.UUID
+	UserID    uuid.UUID
+}
+
+func (q *Queries) UpdateScheduledChirp(ctx context.Context, arg UpdateScheduledChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateScheduledChirp,
+		arg.Body,
+		arg.PublishAt,
+		arg.ID,
+		arg.UserID,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Kind,
+		&i.ReferencedChirpID,
+		&i.DeletedAt,
+		&i.Scheduled,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.InReplyToID,
+	)
+	return i, err
+}
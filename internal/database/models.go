@@ -11,12 +11,209 @@ import (
 	"github.com/google/uuid"
 )
 
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Body              string
+	UserID            uuid.UUID
+	Kind              string
+	ReferencedChirpID uuid.NullUUID
+	DeletedAt         sql.NullTime
+	Scheduled         bool
+	PublishAt         sql.NullTime
+	Visibility        string
+	ContentWarning    string
+	Sensitive         bool
+	InReplyToID       uuid.NullUUID
+}
+
+type ChirpEntity struct {
+	ID          uuid.UUID
+	ChirpID     uuid.UUID
+	Kind        string
+	StartOffset int32
+	EndOffset   int32
+	Text        string
+	Value       string
+	HashtagID   uuid.NullUUID
+}
+
+type ChirpFlag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	ChirpID   uuid.UUID
+	Term      string
+}
+
+type ContentFilterRule struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Term      string
+	Action    string
+}
+
+type Conversation struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+	DirectKey sql.NullString
+}
+
+type ConversationMember struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+}
+
+type Draft struct {
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	UserID            uuid.UUID
+	Body              string
+	Kind              string
+	ReferencedChirpID uuid.NullUUID
+	MediaIds          []uuid.UUID
+	InReplyToID       uuid.NullUUID
+	PublishAt         sql.NullTime
+	Visibility        string
+	ContentWarning    string
+	Sensitive         bool
+	HasPoll           bool
+	PollOptions       []string
+	PollClosesAt      sql.NullTime
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Hashtag struct {
+	ID  uuid.UUID
+	Tag string
+}
+
+type Like struct {
 	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type List struct {
+	ID          uuid.UUID
+	OwnerID     uuid.UUID
+	Name        string
+	Description string
+	Visibility  string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type ListMember struct {
+	ListID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ListSubscription struct {
+	ListID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type Medium struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UserID       uuid.UUID
+	ContentType  string
+	StorageKey   string
+	ThumbnailKey string
+	Width        int32
+	Height       int32
+	SizeBytes    int32
+	ChirpID      uuid.NullUUID
+	Position     sql.NullInt32
+}
+
+type Message struct {
+	ID             uuid.UUID
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+	CreatedAt      time.Time
+}
+
+type ModerationAction struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	ChirpID     uuid.UUID
+	ModeratorID uuid.UUID
+	Action      string
+	Reason      string
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID          uuid.UUID
+	RecipientID uuid.UUID
+	Type        string
+	ChirpID     uuid.NullUUID
+	GroupKey    string
+	ActorIds    []uuid.UUID
+	ActorCount  int32
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	ReadAt      sql.NullTime
+}
+
+type NotificationPreference struct {
+	UserID  uuid.UUID
+	Type    string
+	Enabled bool
+}
+
+type Poll struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	ChirpID     uuid.UUID
+	ClosesAt    time.Time
+	FinalizedAt sql.NullTime
+}
+
+type PollOption struct {
+	ID        uuid.UUID
+	PollID    uuid.UUID
+	Position  int32
+	Text      string
+	VoteCount int32
+}
+
+type PollVote struct {
+	PollID    uuid.UUID
+	UserID    uuid.UUID
+	OptionID  uuid.UUID
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,6 +225,18 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type SuppressedTrend struct {
+	Term      string
+	CreatedAt time.Time
+}
+
+type TimelineEntry struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	AuthorID  uuid.UUID
+	CreatedAt time.Time
+}
+
 type User struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
@@ -35,4 +244,23 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    sql.NullBool
+	HideSensitive  bool
+	IsModerator    bool
+	PinnedChirpID  uuid.NullUUID
+	FanoutOnRead   bool
+	DmPolicy       string
+	Handle         sql.NullString
+	DisplayName    string
+	Bio            string
+	AvatarMediaID  uuid.NullUUID
+}
+
+type UserRecommendation struct {
+	UserID         uuid.UUID
+	CandidateID    uuid.UUID
+	Score          float64
+	MutualFollows  int32
+	SharedHashtags int32
+	RecentChirps   int32
+	ComputedAt     time.Time
 }
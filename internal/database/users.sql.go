@@ -7,8 +7,11 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const changeUserEmail = `-- name: ChangeUserEmail :exec
@@ -48,7 +51,7 @@ VALUES (
     $1,
     $2
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, hide_sensitive, is_moderator, pinned_chirp_id, fanout_on_read, dm_policy, handle, display_name, bio, avatar_media_id
 `
 
 type CreateUserParams struct {
@@ -66,6 +69,15 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.HideSensitive,
+		&i.IsModerator,
+		&i.PinnedChirpID,
+		&i.FanoutOnRead,
+		&i.DmPolicy,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
 	)
 	return i, err
 }
@@ -79,8 +91,62 @@ func (q *Queries) DeleteUsers(ctx context.Context) error {
 	return err
 }
 
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, hide_sensitive, is_moderator, pinned_chirp_id, fanout_on_read, dm_policy, handle, display_name, bio, avatar_media_id FROM users WHERE lower(handle) = lower($1)
+`
+
+func (q *Queries) GetUserByHandle(ctx context.Context, handle string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.HideSensitive,
+		&i.IsModerator,
+		&i.PinnedChirpID,
+		&i.FanoutOnRead,
+		&i.DmPolicy,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+	)
+	return i, err
+}
+
+const getUserById = `-- name: GetUserById :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, hide_sensitive, is_moderator, pinned_chirp_id, fanout_on_read, dm_policy, handle, display_name, bio, avatar_media_id FROM users WHERE id=$1
+`
+
+func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserById, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.HideSensitive,
+		&i.IsModerator,
+		&i.PinnedChirpID,
+		&i.FanoutOnRead,
+		&i.DmPolicy,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+	)
+	return i, err
+}
+
 const getUserByRefreshToken = `-- name: GetUserByRefreshToken :one
-SELECT u.id, u.created_at, u.updated_at, u.email, u.hashed_password, u.is_chirpy_red
+SELECT u.id, u.created_at, u.updated_at, u.email, u.hashed_password, u.is_chirpy_red, u.hide_sensitive, u.is_moderator, u.pinned_chirp_id, u.fanout_on_read, u.dm_policy, u.handle, u.display_name, u.bio, u.avatar_media_id
 FROM users u
 JOIN refresh_tokens r ON u.id = r.user_id
 WHERE r.token = $1
@@ -96,12 +162,21 @@ func (q *Queries) GetUserByRefreshToken(ctx context.Context, token string) (User
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.HideSensitive,
+		&i.IsModerator,
+		&i.PinnedChirpID,
+		&i.FanoutOnRead,
+		&i.DmPolicy,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
 	)
 	return i, err
 }
 
 const getUserPasswordByEmail = `-- name: GetUserPasswordByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users WHERE email=$1
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, hide_sensitive, is_moderator, pinned_chirp_id, fanout_on_read, dm_policy, handle, display_name, bio, avatar_media_id FROM users WHERE email=$1
 `
 
 func (q *Queries) GetUserPasswordByEmail(ctx context.Context, email string) (User, error) {
@@ -114,10 +189,105 @@ func (q *Queries) GetUserPasswordByEmail(ctx context.Context, email string) (Use
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.HideSensitive,
+		&i.IsModerator,
+		&i.PinnedChirpID,
+		&i.FanoutOnRead,
+		&i.DmPolicy,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
 	)
 	return i, err
 }
 
+const getUserProfiles = `-- name: GetUserProfiles :many
+SELECT u.id, u.handle, u.display_name, u.bio, u.created_at, m.storage_key AS avatar_key
+FROM users u
+LEFT JOIN media m ON m.id = u.avatar_media_id
+WHERE u.id = ANY($1::uuid[])
+`
+
+type GetUserProfilesRow struct {
+	ID          uuid.UUID
+	Handle      sql.NullString
+	DisplayName string
+	Bio         string
+	CreatedAt   time.Time
+	AvatarKey   sql.NullString
+}
+
+func (q *Queries) GetUserProfiles(ctx context.Context, ids []uuid.UUID) ([]GetUserProfilesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserProfiles, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserProfilesRow
+	for rows.Next() {
+		var i GetUserProfilesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Handle,
+			&i.DisplayName,
+			&i.Bio,
+			&i.CreatedAt,
+			&i.AvatarKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const pinChirp = `-- name: PinChirp :execrows
+UPDATE users SET pinned_chirp_id = $1, updated_at = NOW()
+WHERE id = $2 AND EXISTS (
+    SELECT 1 FROM chirps
+    WHERE chirps.id = $1 AND chirps.user_id = $2
+    AND chirps.deleted_at IS NULL AND NOT chirps.scheduled
+)
+`
+
+type PinChirpParams struct {
+	ChirpID uuid.NullUUID
+	UserID  uuid.UUID
+}
+
+func (q *Queries) PinChirp(ctx context.Context, arg PinChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, pinChirp, arg.ChirpID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const unpinChirp = `-- name: UnpinChirp :execrows
+UPDATE users SET pinned_chirp_id = NULL, updated_at = NOW()
+WHERE id = $1 AND pinned_chirp_id = $2
+`
+
+type UnpinChirpParams struct {
+	ID            uuid.UUID
+	PinnedChirpID uuid.NullUUID
+}
+
+func (q *Queries) UnpinChirp(ctx context.Context, arg UnpinChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unpinChirp, arg.ID, arg.PinnedChirpID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateChirpyRed = `-- name: UpdateChirpyRed :exec
 UPDATE users SET is_chirpy_red = TRUE WHERE id = $1
 `
@@ -126,3 +296,107 @@ func (q *Queries) UpdateChirpyRed(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, updateChirpyRed, id)
 	return err
 }
+
+const updateUserModerator = `-- name: UpdateUserModerator :execrows
+UPDATE users SET is_moderator = $1, updated_at = NOW() WHERE id = $2
+`
+
+type UpdateUserModeratorParams struct {
+	IsModerator bool
+	ID          uuid.UUID
+}
+
+func (q *Queries) UpdateUserModerator(ctx context.Context, arg UpdateUserModeratorParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateUserModerator, arg.IsModerator, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateUserPreferences = `-- name: UpdateUserPreferences :one
+UPDATE users SET hide_sensitive = $1, dm_policy = $2, updated_at = NOW() WHERE id = $3
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, hide_sensitive, is_moderator, pinned_chirp_id, fanout_on_read, dm_policy, handle, display_name, bio, avatar_media_id
+`
+
+type UpdateUserPreferencesParams struct {
+	HideSensitive bool
+	DmPolicy      string
+	ID            uuid.UUID
+}
+
+func (q *Queries) UpdateUserPreferences(ctx context.Context, arg UpdateUserPreferencesParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPreferences, arg.HideSensitive, arg.DmPolicy, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.HideSensitive,
+		&i.IsModerator,
+		&i.PinnedChirpID,
+		&i.FanoutOnRead,
+		&i.DmPolicy,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users SET
+    handle = $1,
+    display_name = $2,
+    bio = $3,
+    avatar_media_id = $4,
+    updated_at = NOW()
+WHERE id = $5
+AND ($4::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM media
+    WHERE media.id = $4 AND media.user_id = $5
+    AND media.chirp_id IS NULL
+))
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, hide_sensitive, is_moderator, pinned_chirp_id, fanout_on_read, dm_policy, handle, display_name, bio, avatar_media_id
+`
+
+type UpdateUserProfileParams struct {
+	Handle        sql.NullString
+	DisplayName   string
+	Bio           string
+	AvatarMediaID uuid.NullUUID
+	ID            uuid.UUID
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Bio,
+		arg.AvatarMediaID,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.HideSensitive,
+		&i.IsModerator,
+		&i.PinnedChirpID,
+		&i.FanoutOnRead,
+		&i.DmPolicy,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+	)
+	return i, err
+}
@@ -0,0 +1,90 @@
+package entities
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	KindHashtag = "hashtag"
+	KindMention = "mention"
+	KindURL     = "url"
+)
+
+// Entity is a hashtag, mention or URL found in a chirp body. Start and End are
+// offsets in runes, so clients can slice the body without knowing how it was
+// encoded. Value is the normalized form used for lookups.
+type Entity struct {
+	Kind  string
+	Start int
+	End   int
+	Text  string
+	Value string
+}
+
+var (
+	urlPattern     = regexp.MustCompile(`https?://[^\s]+`)
+	hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/])(#[\p{L}\p{N}_]*\p{L}[\p{L}\p{N}_]*)`)
+	mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.])(@[A-Za-z0-9_]+)`)
+)
+
+// Parse returns the entities in text ordered by their start offset. Hashtags
+// and mentions inside a URL are not reported separately.
+func Parse(text string) []Entity {
+	type span struct{ start, end int }
+	var urls []span
+	var found []Entity
+
+	for _, loc := range urlPattern.FindAllStringIndex(text, -1) {
+		end := loc[0] + len(strings.TrimRight(text[loc[0]:loc[1]], ".,!?;:'\")]"))
+		urls = append(urls, span{loc[0], end})
+		found = append(found, newEntity(text, KindURL, loc[0], end, text[loc[0]:end]))
+	}
+
+	insideURL := func(start int) bool {
+		for _, u := range urls {
+			if start >= u.start && start < u.end {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, loc := range hashtagPattern.FindAllStringSubmatchIndex(text, -1) {
+		if insideURL(loc[2]) {
+			continue
+		}
+		found = append(found, newEntity(text, KindHashtag, loc[2], loc[3], NormalizeHashtag(text[loc[2]:loc[3]])))
+	}
+
+	for _, loc := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		if insideURL(loc[2]) {
+			continue
+		}
+		found = append(found, newEntity(text, KindMention, loc[2], loc[3], strings.ToLower(text[loc[2]+1:loc[3]])))
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Start < found[j].Start
+	})
+
+	return found
+}
+
+// NormalizeHashtag lowercases a tag and strips its leading '#'.
+func NormalizeHashtag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+func newEntity(text, kind string, start, end int, value string) Entity {
+	runeStart := utf8.RuneCountInString(text[:start])
+	return Entity{
+		Kind:  kind,
+		Start: runeStart,
+		End:   runeStart + utf8.RuneCountInString(text[start:end]),
+		Text:  text[start:end],
+		Value: value,
+	}
+}
@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []Entity
+	}{
+		{
+			input: "Hello #Golang and @Gopher!",
+			expected: []Entity{
+				{Kind: KindHashtag, Start: 6, End: 13, Text: "#Golang", Value: "golang"},
+				{Kind: KindMention, Start: 18, End: 25, Text: "@Gopher", Value: "gopher"},
+			},
+		},
+		{
+			input: "Çok güzel #İstanbul",
+			expected: []Entity{
+				{Kind: KindHashtag, Start: 10, End: 19, Text: "#İstanbul", Value: "istanbul"},
+			},
+		},
+		{
+			input: "see https://example.com/a#b, not #1 or me@example.com",
+			expected: []Entity{
+				{Kind: KindURL, Start: 4, End: 27, Text: "https://example.com/a#b", Value: "https://example.com/a#b"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		actual := Parse(c.input)
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("Parse(%q) = %+v, expected %+v", c.input, actual, c.expected)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	MaxUploadSize = 5 << 20
+	ThumbnailSize = 320
+	MaxDimension  = 8192
+	MaxPixels     = 40_000_000
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported media type")
+	ErrTooLarge        = errors.New("image dimensions too large")
+)
+
+// Image is an uploaded image after processing. Data is re-encoded from the
+// decoded pixels, which drops EXIF and any other embedded metadata.
+type Image struct {
+	ContentType          string
+	Extension            string
+	Data                 []byte
+	Thumbnail            []byte
+	ThumbnailContentType string
+	ThumbnailExtension   string
+	Width                int
+	Height               int
+}
+
+// Process sniffs the content type of data instead of trusting the client,
+// strips metadata and generates a thumbnail no larger than ThumbnailSize.
+// The dimensions in the header are checked before decoding, since a small
+// file can declare an image that takes gigabytes to decode.
+func Process(data []byte) (*Image, error) {
+	contentType := http.DetectContentType(data)
+
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if config.Width > MaxDimension || config.Height > MaxDimension ||
+			config.Width*config.Height > MaxPixels {
+			return nil, ErrTooLarge
+		}
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	var img image.Image
+	var out bytes.Buffer
+	result := &Image{ContentType: contentType}
+
+	switch contentType {
+	case "image/jpeg":
+		decoded, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if err := jpeg.Encode(&out, decoded, &jpeg.Options{Quality: 90}); err != nil {
+			return nil, err
+		}
+		img = decoded
+		result.Extension = ".jpg"
+	case "image/png":
+		decoded, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if err := png.Encode(&out, decoded); err != nil {
+			return nil, err
+		}
+		img = decoded
+		result.Extension = ".png"
+	case "image/gif":
+		decoded, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if err := gif.EncodeAll(&out, decoded); err != nil {
+			return nil, err
+		}
+		img = decoded.Image[0]
+		result.Extension = ".gif"
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	result.Data = out.Bytes()
+	result.Width = img.Bounds().Dx()
+	result.Height = img.Bounds().Dy()
+
+	var thumb bytes.Buffer
+	small := thumbnail(img, ThumbnailSize)
+	if contentType == "image/jpeg" {
+		if err := jpeg.Encode(&thumb, small, &jpeg.Options{Quality: 80}); err != nil {
+			return nil, err
+		}
+		result.ThumbnailContentType = "image/jpeg"
+		result.ThumbnailExtension = ".jpg"
+	} else {
+		if err := png.Encode(&thumb, small); err != nil {
+			return nil, err
+		}
+		result.ThumbnailContentType = "image/png"
+		result.ThumbnailExtension = ".png"
+	}
+	result.Thumbnail = thumb.Bytes()
+
+	return result, nil
+}
+
+// thumbnail scales src down so neither side exceeds size, averaging the
+// source pixels that fall into each destination pixel.
+func thumbnail(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return src
+	}
+
+	thumbWidth, thumbHeight := size, max(height*size/width, 1)
+	if height > width {
+		thumbWidth, thumbHeight = max(width*size/height, 1), size
+	}
+
+	dst := image.NewRGBA64(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for y := 0; y < thumbHeight; y++ {
+		y0 := bounds.Min.Y + y*height/thumbHeight
+		y1 := max(bounds.Min.Y+(y+1)*height/thumbHeight, y0+1)
+		for x := 0; x < thumbWidth; x++ {
+			x0 := bounds.Min.X + x*width/thumbWidth
+			x1 := max(bounds.Min.X+(x+1)*width/thumbWidth, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
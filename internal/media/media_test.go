@@ -0,0 +1,50 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestProcess(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 800, 400))); err != nil {
+		t.Fatalf("encoding fixture: %v", err)
+	}
+
+	img, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if img.ContentType != "image/png" || img.Width != 800 || img.Height != 400 {
+		t.Errorf("Unexpected image %s %dx%d", img.ContentType, img.Width, img.Height)
+	}
+
+	thumb, err := png.Decode(bytes.NewReader(img.Thumbnail))
+	if err != nil {
+		t.Fatalf("Decoding thumbnail failed: %v", err)
+	}
+	if thumb.Bounds().Dx() != ThumbnailSize || thumb.Bounds().Dy() != ThumbnailSize/2 {
+		t.Errorf("Expected %dx%d thumbnail, got %v", ThumbnailSize, ThumbnailSize/2, thumb.Bounds())
+	}
+
+	if _, err := Process([]byte("just some text")); err != ErrUnsupportedType {
+		t.Errorf("Expected ErrUnsupportedType, got %v", err)
+	}
+
+	// Only the header is read, so the huge image is never allocated.
+	buf.Reset()
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatalf("encoding fixture: %v", err)
+	}
+	huge := buf.Bytes()
+	binary.BigEndian.PutUint32(huge[16:], 50000)
+	binary.BigEndian.PutUint32(huge[20:], 50000)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+	if _, err := Process(huge); err != ErrTooLarge {
+		t.Errorf("Expected ErrTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package textlen
+
+import (
+	"unicode"
+
+	"github.com/AhmettCelik/web-server/internal/entities"
+)
+
+const zeroWidthJoiner = '\u200d'
+
+// Graphemes counts user perceived characters in text. It follows the parts of
+// the Unicode extended grapheme cluster rules that matter for chirps:
+// combining marks and variation selectors stay with their base, emoji joined
+// with a zero width joiner or followed by a skin tone modifier count once,
+// a pair of regional indicators is one flag and CRLF is one line break.
+func Graphemes(text string) int {
+	count := 0
+	prev := rune(-1)
+	flagOpen := false
+
+	for _, r := range text {
+		var newCluster bool
+		switch {
+		case prev < 0:
+			newCluster = true
+		case isExtend(r), prev == '\r' && r == '\n', prev == zeroWidthJoiner:
+			newCluster = false
+		case isRegionalIndicator(r) && flagOpen:
+			newCluster = false
+		default:
+			newCluster = true
+		}
+
+		if newCluster {
+			count++
+			flagOpen = isRegionalIndicator(r)
+		} else if isRegionalIndicator(r) {
+			flagOpen = false
+		}
+		prev = r
+	}
+
+	return count
+}
+
+// Weighted counts text the way chirp limits are checked: every URL counts as
+// urlWeight no matter how long it is, everything else counts in graphemes.
+func Weighted(text string, urlWeight int) int {
+	runes := []rune(text)
+	count := 0
+	offset := 0
+
+	for _, entity := range entities.Parse(text) {
+		if entity.Kind != entities.KindURL {
+			continue
+		}
+		count += Graphemes(string(runes[offset:entity.Start])) + urlWeight
+		offset = entity.End
+	}
+
+	return count + Graphemes(string(runes[offset:]))
+}
+
+// isExtend reports whether r attaches to the character before it. Variation
+// selectors are nonspacing marks, so they are covered by unicode.Mn.
+func isExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r == zeroWidthJoiner ||
+		(r >= 0x1f3fb && r <= 0x1f3ff) ||
+		(r >= 0xe0020 && r <= 0xe007f)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
@@ -0,0 +1,32 @@
+package textlen
+
+import "testing"
+
+func TestGraphemes(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected int
+	}{
+		{input: "hello", expected: 5},
+		{input: "Günaydın İstanbul", expected: 17},
+		{input: "é", expected: 1},
+		{input: "👍🏽", expected: 1},
+		{input: "👨‍👩‍👧‍👦", expected: 1},
+		{input: "🇹🇷🇺🇸", expected: 2},
+		{input: "❤️", expected: 1},
+		{input: "a\r\nb", expected: 3},
+	}
+
+	for _, c := range cases {
+		if actual := Graphemes(c.input); actual != c.expected {
+			t.Errorf("Graphemes(%q) = %d, expected %d", c.input, actual, c.expected)
+		}
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	text := "read https://example.com/a/very/long/path/that/goes/on/and/on 👍🏽"
+	if actual := Weighted(text, 23); actual != 5+23+2 {
+		t.Errorf("Weighted(%q) = %d, expected %d", text, actual, 5+23+2)
+	}
+}
@@ -0,0 +1,73 @@
+// Package trends ranks hashtags by how much more they are used now than they
+// usually are.
+package trends
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// MinUsers is how many people must use a tag within the window before it can
+// trend, so one person posting a new tag can't put it on the list.
+const MinUsers = 3
+
+// Usage is how many distinct users used a tag in the trend window, and the
+// same count summed over each window-sized bucket of the baseline period
+// before it. Distinct users don't add up across time, so counting them over
+// the whole baseline at once would make a tag used by the same people every
+// hour look like it is spiking.
+type Usage struct {
+	Tag      string
+	Recent   int64
+	Baseline int64
+}
+
+// Trend is a ranked hashtag. Count is its usage in the window.
+type Trend struct {
+	Tag   string
+	Count int64
+	Score float64
+}
+
+// Rank scores each tag by how far its usage in the window is above what its
+// baseline predicts, measured in standard deviations of a Poisson process.
+// Tags that are always popular score near zero unless they spike, and tags
+// with no history score by their raw usage. The top limit trends with a
+// positive score are returned, highest first.
+func Rank(usage []Usage, window, baseline time.Duration, limit int) []Trend {
+	var ratio float64
+	if window > 0 && baseline > 0 {
+		ratio = float64(window) / float64(baseline)
+	}
+
+	var ranked []Trend
+	for _, u := range usage {
+		if u.Recent < MinUsers {
+			continue
+		}
+
+		expected := float64(u.Baseline) * ratio
+		score := (float64(u.Recent) - expected) / math.Sqrt(expected+1)
+		if !(score > 0) {
+			continue
+		}
+
+		ranked = append(ranked, Trend{Tag: u.Tag, Count: u.Recent, Score: score})
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		if ranked[i].Count != ranked[j].Count {
+			return ranked[i].Count > ranked[j].Count
+		}
+		return ranked[i].Tag < ranked[j].Tag
+	})
+
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
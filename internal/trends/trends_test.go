@@ -0,0 +1,77 @@
+package trends
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRank(t *testing.T) {
+	window := time.Hour
+	baseline := 24 * time.Hour
+
+	cases := []struct {
+		name     string
+		usage    []Usage
+		limit    int
+		expected []string
+	}{
+		{
+			name: "new tag beats always popular tag",
+			usage: []Usage{
+				{Tag: "golang", Recent: 100, Baseline: 2400},
+				{Tag: "gophercon", Recent: 20, Baseline: 0},
+			},
+			limit:    10,
+			expected: []string{"gophercon"},
+		},
+		{
+			name: "popular tag trends when it spikes",
+			usage: []Usage{
+				{Tag: "golang", Recent: 400, Baseline: 2400},
+				{Tag: "gophercon", Recent: 20, Baseline: 0},
+			},
+			limit:    10,
+			expected: []string{"golang", "gophercon"},
+		},
+		{
+			name: "same users every hour do not trend",
+			usage: []Usage{
+				{Tag: "daily", Recent: 50, Baseline: 50 * 24},
+				{Tag: "gophercon", Recent: 20, Baseline: 0},
+			},
+			limit:    10,
+			expected: []string{"gophercon"},
+		},
+		{
+			name: "too few users to trend",
+			usage: []Usage{
+				{Tag: "mytag", Recent: MinUsers - 1, Baseline: 0},
+			},
+			limit:    10,
+			expected: nil,
+		},
+		{
+			name: "limit and tie order",
+			usage: []Usage{
+				{Tag: "b", Recent: 5, Baseline: 0},
+				{Tag: "a", Recent: 5, Baseline: 0},
+				{Tag: "c", Recent: 4, Baseline: 0},
+			},
+			limit:    2,
+			expected: []string{"a", "b"},
+		},
+	}
+
+	for _, c := range cases {
+		actual := Rank(c.usage, window, baseline, c.limit)
+		if len(actual) != len(c.expected) {
+			t.Errorf("%s: got %d trends, expected %d", c.name, len(actual), len(c.expected))
+			continue
+		}
+		for i, trend := range actual {
+			if trend.Tag != c.expected[i] {
+				t.Errorf("%s: trend %d is %q, expected %q", c.name, i, trend.Tag, c.expected[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,337 @@
+// Package websocket implements the server side of the WebSocket protocol
+// (RFC 6455): the opening handshake, message framing with fragmentation, and
+// the ping and close control frames. Extensions and subprotocols are not
+// supported.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// Close codes from RFC 6455 section 7.4.
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseTryAgainLater   = 1013
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// DefaultMaxMessageSize limits how large a message a client may send.
+const DefaultMaxMessageSize = 64 << 10
+
+var (
+	errProtocol        = errors.New("websocket: protocol error")
+	errMessageTooBig   = errors.New("websocket: message too big")
+	errInvalidUTF8     = errors.New("websocket: text message is not valid UTF-8")
+	errNotWebSocket    = errors.New("websocket: not a websocket handshake")
+	errUnsupportedVers = errors.New("websocket: unsupported version")
+)
+
+// CloseError is returned by ReadMessage when the client closes the
+// connection.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed by peer with code %d %s", e.Code, e.Reason)
+}
+
+// Conn is a server side WebSocket connection. One goroutine may read while
+// others write; writes are serialized.
+type Conn struct {
+	conn net.Conn
+	br   *bufio.Reader
+	wmu  sync.Mutex
+
+	// MaxMessageSize is the largest message ReadMessage accepts.
+	MaxMessageSize int64
+	// ReadTimeout, if set, is how long to wait for each frame, pongs
+	// included, before the read fails.
+	ReadTimeout time.Duration
+	// WriteTimeout, if set, bounds every write.
+	WriteTimeout time.Duration
+}
+
+// AcceptKey computes the Sec-WebSocket-Accept value for a client's
+// Sec-WebSocket-Key.
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Upgrade completes the opening handshake and takes over the connection. On
+// failure it responds with an HTTP error and returns it.
+func Upgrade(w http.ResponseWriter, req *http.Request) (*Conn, error) {
+	if req.Method != http.MethodGet ||
+		!headerContainsToken(req.Header, "Connection", "upgrade") ||
+		!headerContainsToken(req.Header, "Upgrade", "websocket") {
+		http.Error(w, "Expected a websocket handshake", http.StatusBadRequest)
+		return nil, errNotWebSocket
+	}
+
+	if req.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, errUnsupportedVers
+	}
+
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		http.Error(w, "Invalid Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errNotWebSocket
+	}
+
+	netConn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "Websocket upgrade not supported", http.StatusInternalServerError)
+		return nil, err
+	}
+	// The server may have set deadlines for ordinary requests.
+	netConn.SetDeadline(time.Time{})
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + AcceptKey(key) + "\r\n\r\n"
+	if _, err := brw.WriteString(response); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if err := brw.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return newConn(netConn, brw.Reader), nil
+}
+
+func newConn(netConn net.Conn, br *bufio.Reader) *Conn {
+	return &Conn{
+		conn:           netConn,
+		br:             br,
+		MaxMessageSize: DefaultMaxMessageSize,
+	}
+}
+
+// ReadMessage returns the next text or binary message. Pings are answered
+// and pongs skipped along the way. When the client closes the connection the
+// close is echoed and a *CloseError returned; on a protocol violation the
+// connection is closed with the matching code.
+func (c *Conn) ReadMessage() ([]byte, error) {
+	var message []byte
+	var messageOp byte
+	started := false
+
+	for {
+		fin, op, payload, err := c.readFrame()
+		if errors.Is(err, errProtocol) {
+			c.Close(CloseProtocolError, "")
+			return nil, err
+		}
+		if errors.Is(err, errMessageTooBig) {
+			c.Close(CloseMessageTooBig, "")
+			return nil, err
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			closeErr := &CloseError{Code: CloseNormal}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+			}
+			c.Close(CloseNormal, "")
+			return nil, closeErr
+		case opText, opBinary:
+			if started {
+				c.Close(CloseProtocolError, "")
+				return nil, errProtocol
+			}
+			started = true
+			messageOp = op
+			message = payload
+		case opContinuation:
+			if !started {
+				c.Close(CloseProtocolError, "")
+				return nil, errProtocol
+			}
+			if int64(len(message)+len(payload)) > c.MaxMessageSize {
+				c.Close(CloseMessageTooBig, "")
+				return nil, errMessageTooBig
+			}
+			message = append(message, payload...)
+		default:
+			c.Close(CloseProtocolError, "")
+			return nil, errProtocol
+		}
+
+		if fin {
+			if messageOp == opText && !utf8.Valid(message) {
+				c.Close(CloseInvalidPayload, "")
+				return nil, errInvalidUTF8
+			}
+			return message, nil
+		}
+	}
+}
+
+func (c *Conn) readFrame() (bool, byte, []byte, error) {
+	if c.ReadTimeout > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.ReadTimeout))
+	}
+
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	op := header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+	length := int64(header[1] & 0x7f)
+
+	// No extensions are negotiated, so the reserved bits must be clear, and
+	// clients must mask every frame.
+	if header[0]&0x70 != 0 || !masked {
+		return false, 0, nil, errProtocol
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		if ext[0]&0x80 != 0 {
+			return false, 0, nil, errProtocol
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+
+	isControl := op&0x8 != 0
+	if isControl && (length > 125 || !fin) {
+		return false, 0, nil, errProtocol
+	}
+	if length > c.MaxMessageSize {
+		return false, 0, nil, errMessageTooBig
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, op, payload, nil
+}
+
+func (c *Conn) writeFrame(op byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if c.WriteTimeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.WriteTimeout))
+	}
+
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|op)
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, byte(len(payload)))
+	case len(payload) <= 0xffff:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	frame = append(frame, payload...)
+
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// WriteText sends a text message in a single frame.
+func (c *Conn) WriteText(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+// Ping sends a ping the client is expected to answer with a pong.
+func (c *Conn) Ping() error {
+	return c.writeFrame(opPing, nil)
+}
+
+// Close sends a close frame with code and reason, then closes the
+// underlying connection.
+func (c *Conn) Close(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	payload = append(payload, reason...)
+
+	c.writeFrame(opClose, payload)
+	return c.conn.Close()
+}
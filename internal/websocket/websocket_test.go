@@ -0,0 +1,100 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// The example from RFC 6455 section 1.3.
+	if actual := AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); actual != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("AcceptKey = %q, expected %q", actual, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=")
+	}
+}
+
+func clientFrame(fin bool, op byte, payload []byte) []byte {
+	first := op
+	if fin {
+		first |= 0x80
+	}
+	mask := [4]byte{0x12, 0x34, 0x56, 0x78}
+	frame := []byte{first, 0x80 | byte(len(payload))}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	return frame
+}
+
+func TestReadMessage(t *testing.T) {
+	cases := []struct {
+		name     string
+		frames   [][]byte
+		expected string
+		reply    []byte
+		closeErr int
+	}{
+		{
+			name:     "single frame",
+			frames:   [][]byte{clientFrame(true, opText, []byte("hello"))},
+			expected: "hello",
+		},
+		{
+			name: "fragmented with a ping in between",
+			frames: [][]byte{
+				clientFrame(false, opText, []byte("hel")),
+				clientFrame(true, opPing, []byte("p")),
+				clientFrame(true, opContinuation, []byte("lo")),
+			},
+			expected: "hello",
+			reply:    []byte{0x80 | opPong, 1, 'p'},
+		},
+		{
+			name:     "close",
+			frames:   [][]byte{clientFrame(true, opClose, []byte{0x03, 0xe9})},
+			reply:    []byte{0x80 | opClose, 2, 0x03, 0xe8},
+			closeErr: CloseGoingAway,
+		},
+	}
+
+	for _, c := range cases {
+		server, client := net.Pipe()
+		conn := newConn(server, bufio.NewReader(server))
+
+		go func() {
+			for _, frame := range c.frames {
+				client.Write(frame)
+			}
+		}()
+		replies := make(chan []byte)
+		go func() {
+			buf := make([]byte, len(c.reply))
+			io.ReadFull(client, buf)
+			replies <- buf
+		}()
+
+		message, err := conn.ReadMessage()
+		var closeErr *CloseError
+		switch {
+		case c.closeErr != 0:
+			if !errors.As(err, &closeErr) || closeErr.Code != c.closeErr {
+				t.Errorf("%s: got error %v, expected close code %d", c.name, err, c.closeErr)
+			}
+		case err != nil:
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		case string(message) != c.expected:
+			t.Errorf("%s: got %q, expected %q", c.name, message, c.expected)
+		}
+
+		if reply := <-replies; !bytes.Equal(reply, c.reply) {
+			t.Errorf("%s: server replied %v, expected %v", c.name, reply, c.reply)
+		}
+
+		client.Close()
+		server.Close()
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/google/uuid"
+)
+
+// likeChirp likes a chirp the user can see and notifies its author. Liking a
+// chirp twice is a no-op, so the author only hears about it once.
+func (cfg *apiConfig) likeChirp(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating like: %v", err)
+		return
+	}
+
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	chirpDb, err := cfg.db.GetChirpById(context.Background(), database.GetChirpByIdParams{
+		ID:       chirpId,
+		ViewerID: userId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	}
+
+	var liked bool
+	err = cfg.withTx(context.Background(), func(q *database.Queries) error {
+		created, err := q.CreateLike(context.Background(), database.CreateLikeParams{
+			UserID:  userId,
+			ChirpID: chirpId,
+		})
+		if err != nil || created == 0 {
+			return err
+		}
+		liked = true
+
+		return recordEvent(context.Background(), q, domainEvent{
+			Type:        notificationLike,
+			ActorID:     userId,
+			RecipientID: chirpDb.UserID,
+			ChirpID:     uuid.NullUUID{UUID: chirpId, Valid: true},
+		})
+	})
+	if isForeignKeyViolation(err) {
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error liking chirp")
+		log.Printf("Error creating like: %v", err)
+		return
+	}
+
+	if liked {
+		cfg.publishLive(liveEvent{Type: liveNotification, RecipientID: chirpDb.UserID}, nil)
+	}
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) unlikeChirp(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating like: %v", err)
+		return
+	}
+
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	deleted, err := cfg.db.DeleteLike(context.Background(), database.DeleteLikeParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error removing like")
+		log.Printf("Error deleting like: %v", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Chirp is not liked")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,676 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	listVisibilityPublic  = "public"
+	listVisibilityPrivate = "private"
+)
+
+const (
+	maxListNameLength        = 25
+	maxListDescriptionLength = 100
+	maxListMembers           = 500
+)
+
+// list is a named group of accounts curated by its owner. Private lists are
+// only visible to the owner; public lists can be read and subscribed to by
+// anyone.
+type list struct {
+	Id              string `json:"id"`
+	OwnerId         string `json:"owner_id"`
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	Visibility      string `json:"visibility"`
+	MemberCount     int64  `json:"member_count"`
+	SubscriberCount int64  `json:"subscriber_count"`
+	Subscribed      bool   `json:"subscribed"`
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
+}
+
+type listMember struct {
+	UserId  string `json:"user_id"`
+	AddedAt string `json:"added_at"`
+}
+
+type listInput struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility"`
+}
+
+// listsResponse builds the JSON for lists as viewerId sees them, loading
+// member and subscriber counts in one query.
+func (cfg *apiConfig) listsResponse(ctx context.Context, viewerId uuid.UUID, listsDb []database.List) ([]list, error) {
+	var ids []uuid.UUID
+	for _, listDb := range listsDb {
+		ids = append(ids, listDb.ID)
+	}
+
+	counts := map[uuid.UUID]database.GetListCountsRow{}
+	if len(ids) > 0 {
+		countsDb, err := cfg.db.GetListCounts(ctx, database.GetListCountsParams{ViewerID: viewerId, Ids: ids})
+		if err != nil {
+			return nil, err
+		}
+		for _, count := range countsDb {
+			counts[count.ID] = count
+		}
+	}
+
+	listsJson := []list{}
+	for _, listDb := range listsDb {
+		listsJson = append(listsJson, list{
+			Id:              listDb.ID.String(),
+			OwnerId:         listDb.OwnerID.String(),
+			Name:            listDb.Name,
+			Description:     listDb.Description,
+			Visibility:      listDb.Visibility,
+			MemberCount:     counts[listDb.ID].MemberCount,
+			SubscriberCount: counts[listDb.ID].SubscriberCount,
+			Subscribed:      counts[listDb.ID].Subscribed,
+			CreatedAt:       listDb.CreatedAt.String(),
+			UpdatedAt:       listDb.UpdatedAt.String(),
+		})
+	}
+	return listsJson, nil
+}
+
+func (cfg *apiConfig) respondWithList(w http.ResponseWriter, status int, viewerId uuid.UUID, listDb database.List) {
+	listsJson, err := cfg.listsResponse(context.Background(), viewerId, []database.List{listDb})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting list")
+		log.Printf("Error getting list counts: %v", err)
+		return
+	}
+	respondWithJSON(w, status, listsJson[0])
+}
+
+// cleanListInput validates a list's name, description and visibility,
+// returning them with masked words replaced.
+func (cfg *apiConfig) cleanListInput(input listInput) (listInput, error) {
+	if input.Visibility == "" {
+		input.Visibility = listVisibilityPrivate
+	}
+	if input.Visibility != listVisibilityPublic && input.Visibility != listVisibilityPrivate {
+		return listInput{}, errors.New("visibility must be public or private")
+	}
+
+	if strings.TrimSpace(input.Name) == "" {
+		return listInput{}, errors.New("A list needs a name")
+	}
+
+	var err error
+	input.Name, err = cfg.cleanProfileText("Name", input.Name, maxListNameLength)
+	if err != nil {
+		return listInput{}, err
+	}
+	input.Description, err = cfg.cleanProfileText("Description", input.Description, maxListDescriptionLength)
+	if err != nil {
+		return listInput{}, err
+	}
+	return input, nil
+}
+
+// viewableList loads the list in the request path if viewerId may see it.
+// Private lists of other users are reported as not found.
+func (cfg *apiConfig) viewableList(w http.ResponseWriter, req *http.Request, viewerId uuid.UUID) (database.List, bool) {
+	listId, err := uuid.Parse(req.PathValue("listID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return database.List{}, false
+	}
+
+	listDb, err := cfg.db.GetList(context.Background(), database.GetListParams{ID: listId, ViewerID: viewerId})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "List not found")
+		return database.List{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting list")
+		log.Printf("Error getting list: %v", err)
+		return database.List{}, false
+	}
+	return listDb, true
+}
+
+// ownedList loads the list in the request path if userId owns it.
+func (cfg *apiConfig) ownedList(w http.ResponseWriter, req *http.Request, userId uuid.UUID) (database.List, bool) {
+	listDb, ok := cfg.viewableList(w, req, userId)
+	if !ok {
+		return database.List{}, false
+	}
+	if listDb.OwnerID != userId {
+		respondWithError(w, http.StatusForbidden, "You can not change this list")
+		return database.List{}, false
+	}
+	return listDb, true
+}
+
+func (cfg *apiConfig) createList(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating lists: %v", err)
+		return
+	}
+
+	input := listInput{}
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	input, err = cfg.cleanListInput(input)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	listDb, err := cfg.db.CreateList(context.Background(), database.CreateListParams{
+		OwnerID:     userId,
+		Name:        input.Name,
+		Description: input.Description,
+		Visibility:  input.Visibility,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating list")
+		log.Printf("Error creating list: %v", err)
+		return
+	}
+
+	cfg.respondWithList(w, http.StatusCreated, userId, listDb)
+}
+
+// getLists lists the lists the caller owns, newest first.
+func (cfg *apiConfig) getLists(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating lists: %v", err)
+		return
+	}
+
+	limit, cursor, err := pageParams(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	listsDb, err := cfg.db.GetListsForOwner(context.Background(), database.GetListsForOwnerParams{
+		OwnerID:         userId,
+		BeforeCreatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		PageSize:        limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting lists")
+		log.Printf("Error getting lists: %v", err)
+		return
+	}
+
+	listsJson, err := cfg.listsResponse(context.Background(), userId, listsDb)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting lists")
+		log.Printf("Error getting list counts: %v", err)
+		return
+	}
+
+	lists := page[list]{Items: listsJson}
+	if len(listsDb) == int(limit) {
+		last := listsDb[len(listsDb)-1]
+		lists.NextCursor = pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.String()
+	}
+
+	respondWithJSON(w, http.StatusOK, lists)
+}
+
+// getSubscribedLists lists the public lists the caller subscribed to, most
+// recent subscription first.
+func (cfg *apiConfig) getSubscribedLists(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating lists: %v", err)
+		return
+	}
+
+	limit, cursor, err := pageParams(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := cfg.db.GetSubscribedLists(context.Background(), database.GetSubscribedListsParams{
+		UserID:          userId,
+		BeforeCreatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		PageSize:        limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting lists")
+		log.Printf("Error getting subscribed lists: %v", err)
+		return
+	}
+
+	var listsDb []database.List
+	for _, row := range rows {
+		listsDb = append(listsDb, database.List{
+			ID:          row.ID,
+			OwnerID:     row.OwnerID,
+			Name:        row.Name,
+			Description: row.Description,
+			Visibility:  row.Visibility,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+		})
+	}
+
+	listsJson, err := cfg.listsResponse(context.Background(), userId, listsDb)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting lists")
+		log.Printf("Error getting list counts: %v", err)
+		return
+	}
+
+	lists := page[list]{Items: listsJson}
+	if len(rows) == int(limit) {
+		last := rows[len(rows)-1]
+		lists.NextCursor = pageCursor{CreatedAt: last.SubscribedAt, ID: last.ID}.String()
+	}
+
+	respondWithJSON(w, http.StatusOK, lists)
+}
+
+func (cfg *apiConfig) getList(w http.ResponseWriter, req *http.Request) {
+	viewerId, err := cfg.viewer(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	listDb, ok := cfg.viewableList(w, req, viewerId)
+	if !ok {
+		return
+	}
+
+	cfg.respondWithList(w, http.StatusOK, viewerId, listDb)
+}
+
+// updateList changes a list's name, description or visibility. Fields left
+// out of the request keep their current value. Making a list private drops
+// its subscribers, since they can no longer see it.
+func (cfg *apiConfig) updateList(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating lists: %v", err)
+		return
+	}
+
+	listDb, ok := cfg.ownedList(w, req, userId)
+	if !ok {
+		return
+	}
+
+	input := listInput{Name: listDb.Name, Description: listDb.Description, Visibility: listDb.Visibility}
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	input, err = cfg.cleanListInput(input)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.withTx(context.Background(), func(q *database.Queries) error {
+		var err error
+		listDb, err = q.UpdateList(context.Background(), database.UpdateListParams{
+			Name:        input.Name,
+			Description: input.Description,
+			Visibility:  input.Visibility,
+			ID:          listDb.ID,
+			OwnerID:     userId,
+		})
+		if err != nil {
+			return err
+		}
+		if listDb.Visibility == listVisibilityPrivate {
+			return q.DeleteListSubscriptions(context.Background(), listDb.ID)
+		}
+		return nil
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "List not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating list")
+		log.Printf("Error updating list: %v", err)
+		return
+	}
+
+	cfg.respondWithList(w, http.StatusOK, userId, listDb)
+}
+
+func (cfg *apiConfig) deleteList(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating lists: %v", err)
+		return
+	}
+
+	listDb, ok := cfg.ownedList(w, req, userId)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteList(context.Background(), database.DeleteListParams{ID: listDb.ID, OwnerID: userId})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting list")
+		log.Printf("Error deleting list: %v", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "List not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) getListMembers(w http.ResponseWriter, req *http.Request) {
+	viewerId, err := cfg.viewer(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	listDb, ok := cfg.viewableList(w, req, viewerId)
+	if !ok {
+		return
+	}
+
+	limit, cursor, err := pageParams(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	membersDb, err := cfg.db.GetListMembers(context.Background(), database.GetListMembersParams{
+		ListID:          listDb.ID,
+		BeforeCreatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		PageSize:        limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting list members")
+		log.Printf("Error getting list members: %v", err)
+		return
+	}
+
+	members := page[listMember]{Items: []listMember{}}
+	for _, memberDb := range membersDb {
+		members.Items = append(members.Items, listMember{
+			UserId:  memberDb.UserID.String(),
+			AddedAt: memberDb.CreatedAt.String(),
+		})
+	}
+	if len(membersDb) == int(limit) {
+		last := membersDb[len(membersDb)-1]
+		members.NextCursor = pageCursor{CreatedAt: last.CreatedAt, ID: last.UserID}.String()
+	}
+
+	respondWithJSON(w, http.StatusOK, members)
+}
+
+// addListMember adds an account to a list the caller owns. Adding someone
+// who is already a member does nothing. Users who blocked each other can't
+// be put on each other's lists.
+func (cfg *apiConfig) addListMember(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating lists: %v", err)
+		return
+	}
+
+	listDb, ok := cfg.ownedList(w, req, userId)
+	if !ok {
+		return
+	}
+
+	memberId, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	blocked, err := cfg.db.IsBlockedEitherWay(context.Background(), database.IsBlockedEitherWayParams{
+		UserID:  userId,
+		OtherID: memberId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating list")
+		log.Printf("Error checking blocks: %v", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "You can not add this user")
+		return
+	}
+
+	// The query checks ownership, blocks and the member limit again, so
+	// concurrent requests can't get around them. Existing members count as
+	// added, so a full list only turns away new ones.
+	added, err := cfg.db.AddListMember(context.Background(), database.AddListMemberParams{
+		UserID:     memberId,
+		ListID:     listDb.ID,
+		OwnerID:    userId,
+		MaxMembers: maxListMembers,
+	})
+	if isForeignKeyViolation(err) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating list")
+		log.Printf("Error adding list member: %v", err)
+		return
+	}
+	if added == 0 {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Lists can have at most %d members", maxListMembers))
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) removeListMember(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating lists: %v", err)
+		return
+	}
+
+	listDb, ok := cfg.ownedList(w, req, userId)
+	if !ok {
+		return
+	}
+
+	memberId, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	removed, err := cfg.db.RemoveListMember(context.Background(), database.RemoveListMemberParams{
+		ListID:  listDb.ID,
+		OwnerID: userId,
+		UserID:  memberId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating list")
+		log.Printf("Error removing list member: %v", err)
+		return
+	}
+	if removed == 0 {
+		respondWithError(w, http.StatusNotFound, "User is not on this list")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) subscribeToList(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating lists: %v", err)
+		return
+	}
+
+	listDb, ok := cfg.viewableList(w, req, userId)
+	if !ok {
+		return
+	}
+	if listDb.OwnerID == userId {
+		respondWithError(w, http.StatusBadRequest, "You can not subscribe to your own list")
+		return
+	}
+
+	// Only public lists can be subscribed to; the query checks this again in
+	// case the list was made private in the meantime. Lists of users the
+	// caller blocked or was blocked by can't be subscribed to either.
+	subscribed, err := cfg.db.SubscribeToList(context.Background(), database.SubscribeToListParams{
+		UserID: userId,
+		ListID: listDb.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error subscribing to list")
+		log.Printf("Error subscribing to list: %v", err)
+		return
+	}
+	if subscribed == 0 {
+		respondWithError(w, http.StatusNotFound, "List not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) unsubscribeFromList(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating lists: %v", err)
+		return
+	}
+
+	listId, err := uuid.Parse(req.PathValue("listID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	deleted, err := cfg.db.UnsubscribeFromList(context.Background(), database.UnsubscribeFromListParams{
+		ListID: listId,
+		UserID: userId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error unsubscribing from list")
+		log.Printf("Error unsubscribing from list: %v", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Not subscribed")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// getListTimeline works like the home timeline, but with chirps from the
+// list's members only. Lists change whenever members are added or removed,
+// so the timeline is read straight from chirps rather than fanned out.
+func (cfg *apiConfig) getListTimeline(w http.ResponseWriter, req *http.Request) {
+	viewerId, err := cfg.viewer(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	listDb, ok := cfg.viewableList(w, req, viewerId)
+	if !ok {
+		return
+	}
+
+	limit, cursor, err := pageParams(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, err := cfg.db.GetListTimeline(context.Background(), database.GetListTimelineParams{
+		ListID:          listDb.ID,
+		BeforeCreatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		ViewerID:        viewerId,
+		PageSize:        limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting timeline")
+		log.Printf("Error getting list timeline: %v", err)
+		return
+	}
+
+	var chirpIds []uuid.UUID
+	for _, entry := range entries {
+		chirpIds = append(chirpIds, entry.ChirpID)
+	}
+
+	chirpsDb, err := cfg.chirpsInOrder(context.Background(), viewerId, chirpIds)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting timeline")
+		log.Printf("Error getting timeline chirps: %v", err)
+		return
+	}
+
+	chirpsJson, err := cfg.chirpsResponse(context.Background(), viewerId, chirpsDb)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading chirp details")
+		log.Printf("Error loading chirp details: %v", err)
+		return
+	}
+
+	chirpsJson, err = cfg.filterSensitive(context.Background(), viewerId, chirpsJson)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading preferences")
+		log.Printf("Error loading preferences: %v", err)
+		return
+	}
+
+	timeline := page[chirp]{Items: []chirp{}}
+	timeline.Items = append(timeline.Items, chirpsJson...)
+	if len(entries) == int(limit) {
+		last := entries[len(entries)-1]
+		timeline.NextCursor = pageCursor{CreatedAt: last.CreatedAt, ID: last.ChirpID}.String()
+	}
+
+	respondWithJSON(w, http.StatusOK, timeline)
+}
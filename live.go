@@ -0,0 +1,629 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AhmettCelik/web-server/internal/auth"
+	"github.com/AhmettCelik/web-server/internal/broker"
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/AhmettCelik/web-server/internal/websocket"
+	"github.com/google/uuid"
+)
+
+const (
+	liveSendBuffer     = 64
+	liveReplyBuffer    = 16
+	livePingInterval   = 30 * time.Second
+	liveReadTimeout    = 2 * livePingInterval
+	liveWriteTimeout   = 10 * time.Second
+	liveAuthGrace      = 30 * time.Second
+	liveTypingInterval = 3 * time.Second
+	liveMaxMessageSize = 4096
+	liveMaxTopics      = 100
+)
+
+// Close code for a token that expired without being replaced. Codes from
+// 4000 are left to applications by RFC 6455.
+const liveCloseTokenExpired = 4001
+
+const (
+	liveTopicTimeline      = "timeline"
+	liveTopicNotifications = "notifications"
+	liveConversationPrefix = "conversation:"
+)
+
+const (
+	liveChirpCreated   = "chirp.created"
+	liveChirpDeleted   = "chirp.deleted"
+	liveNotification   = "notification"
+	liveFollowChanged  = "follow.changed"
+	liveHiddenChanged  = "hidden.changed"
+	liveMessageCreated = "message.created"
+	liveTyping         = "typing"
+)
+
+// liveEvent is something live connections may need to hear about. Unlike
+// stream events these aren't all public, so each connection checks the
+// fields besides Data against what its user may see. New chirps carry the
+// chirp instead of Data, since poll votes, bookmarks and quoted chirps
+// depend on who is looking.
+type liveEvent struct {
+	Type           string
+	ActorID        uuid.UUID
+	RecipientID    uuid.UUID
+	Visibility     string
+	ConversationID uuid.UUID
+	Chirp          database.Chirp
+	Data           json.RawMessage
+}
+
+// liveRequest is a message from the client.
+type liveRequest struct {
+	Type  string `json:"type"`
+	Topic string `json:"topic"`
+	Token string `json:"token"`
+}
+
+// liveMessage is a message to the client.
+type liveMessage struct {
+	Type      string          `json:"type"`
+	Topic     string          `json:"topic,omitempty"`
+	Event     string          `json:"event,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+}
+
+func conversationTopic(conversationId uuid.UUID) string {
+	return liveConversationPrefix + conversationId.String()
+}
+
+// canonicalTopic spells conversation topics the way events are matched, so
+// ids in any case work.
+func canonicalTopic(topic string) string {
+	if !strings.HasPrefix(topic, liveConversationPrefix) {
+		return topic
+	}
+	conversationId, err := uuid.Parse(strings.TrimPrefix(topic, liveConversationPrefix))
+	if err != nil {
+		return topic
+	}
+	return conversationTopic(conversationId)
+}
+
+// publishLive marshals data and sends ev to live connections. Like the
+// stream it must only be called once the change is committed.
+func (cfg *apiConfig) publishLive(ev liveEvent, data any) {
+	if data != nil {
+		dataJson, err := json.Marshal(data)
+		if err != nil {
+			log.Printf("Error publishing live event: %v", err)
+			return
+		}
+		ev.Data = dataJson
+	}
+	cfg.live.Publish(ev)
+}
+
+// liveChirpsCreated pushes new chirps of any visibility to live connections
+// and tells the users notified about them to check their notifications. Each
+// connection renders the chirp for its own user.
+func (cfg *apiConfig) liveChirpsCreated(ctx context.Context, chirpsDb []database.Chirp) {
+	for _, chirpDb := range chirpsDb {
+		if chirpDb.Scheduled {
+			continue
+		}
+		cfg.publishLive(liveEvent{
+			Type:       liveChirpCreated,
+			ActorID:    chirpDb.UserID,
+			Visibility: chirpDb.Visibility,
+			Chirp:      chirpDb,
+		}, nil)
+
+		events, err := chirpEvents(ctx, cfg.db, chirpDb)
+		if err != nil {
+			log.Printf("Error publishing live notifications: %v", err)
+			continue
+		}
+		for _, ev := range events {
+			cfg.publishLive(liveEvent{Type: liveNotification, RecipientID: ev.RecipientID}, nil)
+		}
+	}
+}
+
+// liveFollowChanged keeps the timelines of the follower's connections in
+// step with who they follow, and on a follow tells the followee to check
+// their notifications.
+func (cfg *apiConfig) liveFollowChanged(followerId, followeeId uuid.UUID, following bool) {
+	cfg.publishLive(liveEvent{
+		Type:        liveFollowChanged,
+		ActorID:     followerId,
+		RecipientID: followeeId,
+	}, map[string]bool{"following": following})
+}
+
+// liveHiddenChanged tells the timeline connections of both users that a
+// block or mute between them changed, so they reload who they follow and
+// whose chirps they hide. A block also removes follows both ways, which the
+// reload picks up.
+func (cfg *apiConfig) liveHiddenChanged(userId, targetId uuid.UUID) {
+	cfg.publishLive(liveEvent{
+		Type:        liveHiddenChanged,
+		ActorID:     userId,
+		RecipientID: targetId,
+	}, nil)
+}
+
+// liveConn is one WebSocket connection. The topics, follows and hidden
+// authors are read by the broker while publishing, so they are guarded by
+// mu; everything else belongs to either the reading or the writing
+// goroutine.
+type liveConn struct {
+	cfg    *apiConfig
+	ws     *websocket.Conn
+	userId uuid.UUID
+
+	mu        sync.Mutex
+	topics    map[string]bool
+	following map[uuid.UUID]bool
+	hidden    map[uuid.UUID]bool
+
+	replies chan liveMessage
+	reauth  chan time.Time
+	done    chan struct{}
+
+	// Owned by the reader.
+	lastTyping map[string]time.Time
+	// Owned by the writer.
+	lastNotification string
+}
+
+func (lc *liveConn) match(ev liveEvent) bool {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	switch ev.Type {
+	case liveChirpCreated, liveChirpDeleted:
+		if !lc.topics[liveTopicTimeline] || lc.hidden[ev.ActorID] {
+			return false
+		}
+		if ev.ActorID == lc.userId {
+			return true
+		}
+		return lc.following[ev.ActorID] && ev.Visibility != chirpVisibilityPrivate
+	case liveNotification:
+		return ev.RecipientID == lc.userId && lc.topics[liveTopicNotifications]
+	case liveFollowChanged:
+		return ev.ActorID == lc.userId || ev.RecipientID == lc.userId && lc.topics[liveTopicNotifications]
+	case liveHiddenChanged:
+		return (ev.ActorID == lc.userId || ev.RecipientID == lc.userId) && lc.topics[liveTopicTimeline]
+	case liveMessageCreated:
+		return lc.topics[conversationTopic(ev.ConversationID)]
+	case liveTyping:
+		return ev.ActorID != lc.userId && lc.topics[conversationTopic(ev.ConversationID)]
+	}
+	return false
+}
+
+// loadTimeline refreshes the follows and hidden authors the timeline topic
+// filters on.
+func (lc *liveConn) loadTimeline(ctx context.Context) error {
+	followeeIds, err := lc.cfg.db.GetFolloweeIds(ctx, lc.userId)
+	if err != nil {
+		return err
+	}
+	hiddenIds, err := lc.cfg.db.GetHiddenAuthors(ctx, lc.userId)
+	if err != nil {
+		return err
+	}
+
+	following := map[uuid.UUID]bool{}
+	for _, id := range followeeIds {
+		following[id] = true
+	}
+	hidden := map[uuid.UUID]bool{}
+	for _, id := range hiddenIds {
+		hidden[id] = true
+	}
+
+	lc.mu.Lock()
+	lc.following, lc.hidden = following, hidden
+	lc.mu.Unlock()
+	return nil
+}
+
+// reply queues a message for the writer. A client that doesn't read its
+// replies is disconnected rather than allowed to stall the reader.
+func (lc *liveConn) reply(msg liveMessage) bool {
+	select {
+	case lc.replies <- msg:
+		return true
+	default:
+		lc.ws.Close(websocket.CloseTryAgainLater, "Too slow")
+		return false
+	}
+}
+
+func (lc *liveConn) replyError(topic, message string) bool {
+	return lc.reply(liveMessage{Type: "error", Topic: topic, Error: message})
+}
+
+// subscribe checks the user may see topic before adding it.
+func (lc *liveConn) subscribe(ctx context.Context, topic string) (string, error) {
+	switch {
+	case topic == liveTopicTimeline:
+		if err := lc.loadTimeline(ctx); err != nil {
+			return "", err
+		}
+	case topic == liveTopicNotifications:
+	case strings.HasPrefix(topic, liveConversationPrefix):
+		conversationId, err := uuid.Parse(strings.TrimPrefix(topic, liveConversationPrefix))
+		if err != nil {
+			return "Cant parse uuid string", nil
+		}
+		_, err = lc.cfg.db.GetConversationMember(ctx, database.GetConversationMemberParams{
+			ConversationID: conversationId,
+			UserID:         lc.userId,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return "Conversation not found", nil
+		}
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "Unknown topic", nil
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if !lc.topics[topic] && len(lc.topics) >= liveMaxTopics {
+		return "Too many subscriptions", nil
+	}
+	lc.topics[topic] = true
+	return "", nil
+}
+
+func (lc *liveConn) unsubscribe(topic string) bool {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if !lc.topics[topic] {
+		return false
+	}
+	delete(lc.topics, topic)
+	return true
+}
+
+func (lc *liveConn) subscribed(topic string) bool {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return lc.topics[topic]
+}
+
+// handleRequest acts on one client message. It returns false once the
+// connection is being closed.
+func (lc *liveConn) handleRequest(ctx context.Context, request liveRequest) bool {
+	request.Topic = canonicalTopic(request.Topic)
+
+	switch request.Type {
+	case "subscribe":
+		problem, err := lc.subscribe(ctx, request.Topic)
+		if err != nil {
+			log.Printf("Error subscribing live connection: %v", err)
+			return lc.replyError(request.Topic, "Error subscribing")
+		}
+		if problem != "" {
+			return lc.replyError(request.Topic, problem)
+		}
+		return lc.reply(liveMessage{Type: "subscribed", Topic: request.Topic})
+
+	case "unsubscribe":
+		if !lc.unsubscribe(request.Topic) {
+			return lc.replyError(request.Topic, "Not subscribed")
+		}
+		return lc.reply(liveMessage{Type: "unsubscribed", Topic: request.Topic})
+
+	case "typing":
+		// Only members can subscribe to a conversation, so the
+		// subscription stands in for the membership check.
+		if !strings.HasPrefix(request.Topic, liveConversationPrefix) || !lc.subscribed(request.Topic) {
+			return lc.replyError(request.Topic, "Subscribe to the conversation first")
+		}
+		if time.Since(lc.lastTyping[request.Topic]) < liveTypingInterval {
+			return true
+		}
+		lc.lastTyping[request.Topic] = time.Now()
+
+		conversationId := uuid.MustParse(strings.TrimPrefix(request.Topic, liveConversationPrefix))
+		lc.cfg.publishLive(liveEvent{
+			Type:           liveTyping,
+			ActorID:        lc.userId,
+			ConversationID: conversationId,
+		}, map[string]string{
+			"conversation_id": conversationId.String(),
+			"user_id":         lc.userId.String(),
+		})
+		return true
+
+	case "auth":
+		userId, expiresAt, err := auth.ValidateJWTWithExpiry(request.Token, lc.cfg.tokenSecret)
+		if err != nil {
+			return lc.replyError("", "Invalid token")
+		}
+		if userId != lc.userId {
+			return lc.replyError("", "Token is for a different user")
+		}
+		select {
+		case lc.reauth <- expiresAt:
+		case <-lc.done:
+			return false
+		}
+		msg := liveMessage{Type: "authenticated"}
+		if !expiresAt.IsZero() {
+			msg.ExpiresAt = &expiresAt
+		}
+		return lc.reply(msg)
+	}
+
+	return lc.replyError("", "Unknown message type")
+}
+
+// render turns an event into the message for this connection. New chirps
+// are rendered as this connection's user sees them. Notification pings
+// become the newest notification and unread count, and are skipped when
+// neither changed since the last one sent, such as when the user turned that
+// kind of notification off.
+func (lc *liveConn) render(ctx context.Context, ev liveEvent) (liveMessage, bool, error) {
+	switch ev.Type {
+	case liveChirpCreated:
+		chirpsJson, err := lc.cfg.chirpsResponse(ctx, lc.userId, []database.Chirp{ev.Chirp})
+		if err != nil {
+			return liveMessage{}, false, err
+		}
+		data, err := json.Marshal(chirpsJson[0])
+		if err != nil {
+			return liveMessage{}, false, err
+		}
+		return liveMessage{Type: "event", Topic: liveTopicTimeline, Event: ev.Type, Data: data}, true, nil
+	case liveChirpDeleted:
+		return liveMessage{Type: "event", Topic: liveTopicTimeline, Event: ev.Type, Data: ev.Data}, true, nil
+	case liveMessageCreated, liveTyping:
+		return liveMessage{Type: "event", Topic: conversationTopic(ev.ConversationID), Event: ev.Type, Data: ev.Data}, true, nil
+	case liveHiddenChanged:
+		return liveMessage{}, false, lc.loadTimeline(ctx)
+	}
+
+	if ev.Type == liveFollowChanged && ev.ActorID == lc.userId {
+		var change struct {
+			Following bool `json:"following"`
+		}
+		if err := json.Unmarshal(ev.Data, &change); err != nil {
+			return liveMessage{}, false, err
+		}
+		lc.mu.Lock()
+		if lc.following != nil {
+			lc.following[ev.RecipientID] = change.Following
+		}
+		subscribed := lc.topics[liveTopicNotifications]
+		lc.mu.Unlock()
+		if ev.RecipientID != lc.userId || !subscribed {
+			return liveMessage{}, false, nil
+		}
+	}
+
+	notificationsDb, err := lc.cfg.db.GetNotifications(ctx, database.GetNotificationsParams{
+		RecipientID:     lc.userId,
+		BeforeUpdatedAt: firstPage.CreatedAt,
+		BeforeID:        firstPage.ID,
+		PageSize:        1,
+	})
+	if err != nil {
+		return liveMessage{}, false, err
+	}
+	unread, err := lc.cfg.db.CountUnreadNotifications(ctx, lc.userId)
+	if err != nil {
+		return liveMessage{}, false, err
+	}
+	if len(notificationsDb) == 0 {
+		return liveMessage{}, false, nil
+	}
+
+	latest := notificationsDb[0]
+	key := latest.ID.String() + latest.UpdatedAt.String()
+	if key == lc.lastNotification {
+		return liveMessage{}, false, nil
+	}
+	lc.lastNotification = key
+
+	data, err := json.Marshal(struct {
+		Notification notification `json:"notification"`
+		UnreadCount  int64        `json:"unread_count"`
+	}{notificationFromDb(latest), unread})
+	if err != nil {
+		return liveMessage{}, false, err
+	}
+	return liveMessage{Type: "event", Topic: liveTopicNotifications, Event: liveNotification, Data: data}, true, nil
+}
+
+func (lc *liveConn) write(msg liveMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return lc.ws.WriteText(data)
+}
+
+// writeLoop is the only writer of messages to the client. When the token
+// expires the client gets a token_expired message and liveAuthGrace to send
+// a fresh one; up to liveSendBuffer events are held back until it does, and
+// the connection is closed if it doesn't or more events arrive than that.
+func (lc *liveConn) writeLoop(ctx context.Context, sub <-chan broker.Event[liveEvent], expiresAt time.Time) {
+	ping := time.NewTicker(livePingInterval)
+	defer ping.Stop()
+
+	var expiry <-chan time.Time
+	var timer *time.Timer
+	if !expiresAt.IsZero() {
+		timer = time.NewTimer(time.Until(expiresAt))
+		defer timer.Stop()
+		expiry = timer.C
+	}
+	expired := false
+	var held []liveEvent
+
+	send := func(ev liveEvent) error {
+		msg, ok, err := lc.render(ctx, ev)
+		if err != nil {
+			log.Printf("Error rendering live event: %v", err)
+			return nil
+		}
+		if !ok {
+			return nil
+		}
+		return lc.write(msg)
+	}
+
+	for {
+		var err error
+		select {
+		case <-lc.done:
+			return
+
+		case ev, ok := <-sub:
+			if !ok {
+				// Dropped by the broker for falling behind.
+				lc.ws.Close(websocket.CloseTryAgainLater, "Too slow")
+				return
+			}
+			if expired {
+				if len(held) >= liveSendBuffer {
+					lc.ws.Close(websocket.CloseTryAgainLater, "Too slow")
+					return
+				}
+				held = append(held, ev.Value)
+				continue
+			}
+			err = send(ev.Value)
+
+		case msg := <-lc.replies:
+			err = lc.write(msg)
+
+		case newExpiresAt := <-lc.reauth:
+			expired = false
+			for _, ev := range held {
+				if err = send(ev); err != nil {
+					break
+				}
+			}
+			held = nil
+			if timer == nil {
+				timer = time.NewTimer(0)
+				defer timer.Stop()
+				expiry = timer.C
+			}
+			if newExpiresAt.IsZero() {
+				timer.Stop()
+				expiry = nil
+			} else {
+				timer.Reset(time.Until(newExpiresAt))
+				expiry = timer.C
+			}
+
+		case <-expiry:
+			if expired {
+				lc.ws.Close(liveCloseTokenExpired, "Token expired")
+				return
+			}
+			expired = true
+			timer.Reset(liveAuthGrace)
+			err = lc.write(liveMessage{Type: "token_expired"})
+
+		case <-ping.C:
+			err = lc.ws.Ping()
+		}
+
+		if err != nil {
+			lc.ws.Close(websocket.CloseGoingAway, "")
+			return
+		}
+	}
+}
+
+// getLive upgrades to a WebSocket that pushes events for the topics the
+// client subscribes to: "timeline" for chirps from the user and the users
+// they follow, "notifications", and "conversation:<id>" for messages and
+// typing in a conversation the user is in. Browsers can't set headers on
+// WebSocket requests, so the token may also be passed as access_token.
+func (cfg *apiConfig) getLive(w http.ResponseWriter, req *http.Request) {
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		token = req.URL.Query().Get("access_token")
+	}
+	userId, expiresAt, err := auth.ValidateJWTWithExpiry(token, cfg.tokenSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating live connection: %v", err)
+		return
+	}
+
+	ws, err := websocket.Upgrade(w, req)
+	if err != nil {
+		log.Printf("Error upgrading live connection: %v", err)
+		return
+	}
+	ws.MaxMessageSize = liveMaxMessageSize
+	ws.ReadTimeout = liveReadTimeout
+	ws.WriteTimeout = liveWriteTimeout
+	defer ws.Close(websocket.CloseNormal, "")
+
+	lc := &liveConn{
+		cfg:        cfg,
+		ws:         ws,
+		userId:     userId,
+		topics:     map[string]bool{},
+		replies:    make(chan liveMessage, liveReplyBuffer),
+		reauth:     make(chan time.Time),
+		done:       make(chan struct{}),
+		lastTyping: map[string]time.Time{},
+	}
+
+	sub, _ := cfg.live.Subscribe(0, liveSendBuffer, lc.match)
+	defer sub.Close()
+
+	ctx := context.Background()
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		lc.writeLoop(ctx, sub.Events(), expiresAt)
+	}()
+	defer func() {
+		close(lc.done)
+		<-writerDone
+	}()
+
+	for {
+		data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var request liveRequest
+		if err := json.Unmarshal(data, &request); err != nil {
+			if !lc.replyError("", "Invalid message") {
+				return
+			}
+			continue
+		}
+		if !lc.handleRequest(ctx, request) {
+			return
+		}
+	}
+}
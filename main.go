@@ -4,59 +4,109 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"sort"
-	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/AhmettCelik/web-server/internal/auth"
+	"github.com/AhmettCelik/web-server/internal/blobstore"
+	"github.com/AhmettCelik/web-server/internal/broker"
+	"github.com/AhmettCelik/web-server/internal/contentfilter"
 	"github.com/AhmettCelik/web-server/internal/database"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
 	db             *database.Queries
+	dbConn         *sql.DB
 	platform       string
 	tokenSecret    string
 	polkaApiKey    string
+	blobs          blobstore.Store
+	restoreWindow  time.Duration
+	adminApiKey    string
+	filterFile     string
+	contentFilter  atomic.Pointer[contentfilter.Filter]
+	trendsWindow   time.Duration
+	trendsBaseline time.Duration
+	trends         atomic.Pointer[trendsSnapshot]
+	stream         *broker.Broker[streamEvent]
+	live           *broker.Broker[liveEvent]
 }
 
 type interpreter struct {
-	Body             string `json:"body"`
-	Email            string `json:"email"`
-	UserId           string `json:"user_id"`
-	Password         string `json:"password"`
-	ExpiresInSeconds int    `json:"expires_in_seconds"`
-	Event            string `json:"event"`
-	Data             struct {
+	Body              string     `json:"body"`
+	Email             string     `json:"email"`
+	UserId            string     `json:"user_id"`
+	Password          string     `json:"password"`
+	ExpiresInSeconds  int        `json:"expires_in_seconds"`
+	Event             string     `json:"event"`
+	Kind              string     `json:"kind"`
+	ReferencedChirpID string     `json:"referenced_chirp_id"`
+	InReplyToID       string     `json:"in_reply_to_id"`
+	MediaIDs          []string   `json:"media_ids"`
+	PublishAt         *time.Time `json:"publish_at"`
+	Visibility        string     `json:"visibility"`
+	ContentWarning    string     `json:"content_warning"`
+	Sensitive         bool       `json:"sensitive"`
+	Poll              *pollInput `json:"poll"`
+	Data              struct {
 		UserID string `json:"user_id"`
 	} `json:"data"`
 }
 
 type user struct {
-	Id           string `json:"id"`
-	CreatedAt    string `json:"created_at"`
-	UpdatedAt    string `json:"updated_at"`
-	Email        string `json:"email"`
-	password     string
-	Token        string `json:"token"`
-	RefreshToken string `json:"refresh_token"`
-	IsChirpyRed  bool   `json:"is_chirpy_red"`
+	Id             string `json:"id"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+	Email          string `json:"email"`
+	password       string
+	Token          string `json:"token"`
+	RefreshToken   string `json:"refresh_token"`
+	IsChirpyRed    bool   `json:"is_chirpy_red"`
+	IsModerator    bool   `json:"is_moderator"`
+	HideSensitive  bool   `json:"hide_sensitive"`
+	DmPolicy       string `json:"dm_policy"`
+	FollowerCount  int64  `json:"follower_count"`
+	FollowingCount int64  `json:"following_count"`
 }
 
 type chirp struct {
-	Id        string `json:"id"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	Body      string `json:"body"`
-	UserId    string `json:"user_id"`
+	Id                     string        `json:"id"`
+	CreatedAt              string        `json:"created_at"`
+	UpdatedAt              string        `json:"updated_at"`
+	Body                   string        `json:"body"`
+	UserId                 string        `json:"user_id"`
+	Author                 *author       `json:"author,omitempty"`
+	Kind                   string        `json:"kind"`
+	Visibility             string        `json:"visibility"`
+	ContentWarning         string        `json:"content_warning,omitempty"`
+	Sensitive              bool          `json:"sensitive"`
+	Pinned                 bool          `json:"pinned,omitempty"`
+	ReferencedChirpId      string        `json:"referenced_chirp_id,omitempty"`
+	ReferencedChirp        *chirp        `json:"referenced_chirp,omitempty"`
+	ReferencedChirpDeleted bool          `json:"referenced_chirp_deleted,omitempty"`
+	InReplyToId            string        `json:"in_reply_to_id,omitempty"`
+	Entities               []chirpEntity `json:"entities"`
+	Poll                   *poll         `json:"poll,omitempty"`
+	Media                  []chirpMedia  `json:"media"`
+	PublishAt              string        `json:"publish_at,omitempty"`
+}
+
+type chirpEntity struct {
+	Type  string `json:"type"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Text  string `json:"text"`
+	Value string `json:"value"`
 }
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
@@ -114,15 +164,63 @@ func respondWithJSON(w http.ResponseWriter, code int, payload any) {
 	w.Write(data)
 }
 
-func breakingBadWords(text string) string {
-	words := strings.Split(text, " ")
-	for i, word := range words {
-		switch strings.ToLower(word) {
-		case "kerfuffle", "sharbert", "fornax":
-			words[i] = "****"
-		}
+// authenticate returns the id of the user whose access token is in the
+// Authorization header.
+func (cfg *apiConfig) authenticate(req *http.Request) (uuid.UUID, error) {
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return auth.ValidateJWT(token, cfg.tokenSecret)
+}
+
+// viewer returns the user making a request that works with or without a
+// token. Anonymous requests get uuid.Nil; a token that is present but
+// invalid is still an error.
+func (cfg *apiConfig) viewer(req *http.Request) (uuid.UUID, error) {
+	if req.Header.Get("Authorization") == "" {
+		return uuid.Nil, nil
+	}
+
+	return cfg.authenticate(req)
+}
+
+// withTx runs fn inside a database transaction, committing only when fn
+// returns nil.
+func (cfg *apiConfig) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(cfg.db.WithTx(tx)); err != nil {
+		return err
 	}
-	return strings.Join(words, " ")
+
+	return tx.Commit()
+}
+
+// isUniqueViolation reports whether err came from a unique constraint in
+// postgres.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// isCheckViolation reports whether err came from a check constraint in
+// postgres.
+func isCheckViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23514"
+}
+
+// isForeignKeyViolation reports whether err came from a foreign key in
+// postgres, such as a reference to a user that doesn't exist.
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
 }
 
 func (cfg *apiConfig) validatePost(w http.ResponseWriter, req *http.Request) {
@@ -134,13 +232,6 @@ func (cfg *apiConfig) validatePost(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	if len(post.Body) > 140 {
-		respondWithError(w, http.StatusBadRequest, "Chirp is too long")
-		return
-	}
-
-	cleanedBody := breakingBadWords(post.Body)
-
 	token, err := auth.GetBearerToken(req.Header)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Bearer token error")
@@ -149,25 +240,45 @@ func (cfg *apiConfig) validatePost(w http.ResponseWriter, req *http.Request) {
 	}
 
 	userId, err := auth.ValidateJWT(token, cfg.tokenSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token")
+		log.Printf("Error validating access token: %v", err)
+		return
+	}
 
-	params := database.CreateChirpParams{
-		UserID: userId,
-		Body:   cleanedBody,
+	prepared, err := cfg.prepareChirp(context.Background(), userId, post)
+	var chirpErr *chirpError
+	if errors.As(err, &chirpErr) {
+		respondWithChirpError(w, chirpErr)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error validating chirp")
+		log.Printf("Error validating chirp: %v", err)
+		return
 	}
 
-	chirpDb, err := cfg.db.CreateChirp(context.Background(), params)
+	var chirpDb database.Chirp
+	err = cfg.withTx(context.Background(), func(q *database.Queries) error {
+		chirpDb, err = insertChirp(context.Background(), q, prepared)
+		return err
+	})
+	if errors.As(err, &chirpErr) {
+		respondWithChirpError(w, chirpErr)
+		return
+	}
 	if err != nil {
 		respondWithError(w, 401, "Something went wrong see the log")
 		log.Printf("Error creating chirp: %v", err)
 		return
 	}
+	cfg.streamChirpsCreated(context.Background(), []database.Chirp{chirpDb})
 
-	chirpJson := chirp{
-		Id:        chirpDb.ID.String(),
-		CreatedAt: chirpDb.CreatedAt.String(),
-		UpdatedAt: chirpDb.UpdatedAt.String(),
-		Body:      cleanedBody,
-		UserId:    userId.String(),
+	chirpJson, err := cfg.chirpResponse(context.Background(), userId, chirpDb)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading chirp details")
+		log.Printf("Error loading chirp details: %v", err)
+		return
 	}
 
 	respondWithJSON(w, 201, chirpJson)
@@ -199,24 +310,33 @@ func (cfg *apiConfig) createUserHandler(w http.ResponseWriter, req *http.Request
 	}
 
 	userJson := user{
-		Id:          userDb.ID.String(),
-		CreatedAt:   userDb.CreatedAt.String(),
-		UpdatedAt:   userDb.UpdatedAt.String(),
-		Email:       userDb.Email,
-		IsChirpyRed: userDb.IsChirpyRed.Bool,
+		Id:            userDb.ID.String(),
+		CreatedAt:     userDb.CreatedAt.String(),
+		UpdatedAt:     userDb.UpdatedAt.String(),
+		Email:         userDb.Email,
+		IsChirpyRed:   userDb.IsChirpyRed.Bool,
+		IsModerator:   userDb.IsModerator,
+		HideSensitive: userDb.HideSensitive,
+		DmPolicy:      userDb.DmPolicy,
 	}
 
 	respondWithJSON(w, 201, userJson)
 }
 
 func (cfg *apiConfig) getChirps(w http.ResponseWriter, req *http.Request) {
+	viewerId, err := cfg.viewer(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
 	authorID := req.URL.Query().Get("author_id")
 	sortType := req.URL.Query().Get("sort")
 	var chirpsDb []database.Chirp
-	var err error
+	var pinnedId uuid.NullUUID
 
 	if authorID == "" {
-		chirpsDb, err = cfg.db.GetChirps(context.Background())
+		chirpsDb, err = cfg.db.GetChirps(context.Background(), viewerId)
 		if err != nil {
 			log.Fatalf("Error getting chirps: %v", err)
 			respondWithError(w, http.StatusBadRequest, "Error getting chirps")
@@ -230,12 +350,20 @@ func (cfg *apiConfig) getChirps(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 
-		chirpsDb, err = cfg.db.GetChirpsForUserID(context.Background(), authorUniqueID)
+		chirpsDb, err = cfg.db.GetChirpsForUserID(context.Background(), database.GetChirpsForUserIDParams{
+			UserID:   authorUniqueID,
+			ViewerID: viewerId,
+		})
 		if err != nil {
 			log.Fatalf("Error getting chirps: %v", err)
 			respondWithError(w, http.StatusBadRequest, "Error getting chirps")
 			return
 		}
+
+		authorDb, err := cfg.db.GetUserById(context.Background(), authorUniqueID)
+		if err == nil {
+			pinnedId = authorDb.PinnedChirpID
+		}
 	}
 
 	if sortType == "desc" {
@@ -244,22 +372,38 @@ func (cfg *apiConfig) getChirps(w http.ResponseWriter, req *http.Request) {
 		})
 	}
 
-	var chirpsJson []chirp
-	for _, chirpDb := range chirpsDb {
-		chirpJson := chirp{
-			Id:        chirpDb.ID.String(),
-			CreatedAt: chirpDb.CreatedAt.String(),
-			UpdatedAt: chirpDb.UpdatedAt.String(),
-			Body:      chirpDb.Body,
-			UserId:    chirpDb.UserID.String(),
-		}
-		chirpsJson = append(chirpsJson, chirpJson)
+	if pinnedId.Valid {
+		chirpsDb = pinFirst(chirpsDb, pinnedId.UUID)
+	}
+
+	chirpsJson, err := cfg.chirpsResponse(context.Background(), viewerId, chirpsDb)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading chirp details")
+		log.Printf("Error loading chirp details: %v", err)
+		return
+	}
+
+	if len(chirpsJson) > 0 && pinnedId.Valid && chirpsJson[0].Id == pinnedId.UUID.String() {
+		chirpsJson[0].Pinned = true
+	}
+
+	chirpsJson, err = cfg.filterSensitive(context.Background(), viewerId, chirpsJson)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading preferences")
+		log.Printf("Error loading preferences: %v", err)
+		return
 	}
 
 	respondWithJSON(w, http.StatusOK, chirpsJson)
 }
 
 func (cfg *apiConfig) getChirpById(w http.ResponseWriter, req *http.Request) {
+	viewerId, err := cfg.viewer(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
 	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
 	if err != nil {
 		log.Printf("Error parsing uuid string: %v", err)
@@ -267,18 +411,28 @@ func (cfg *apiConfig) getChirpById(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	chirpDb, err := cfg.db.GetChirpById(context.Background(), chirpId)
+	chirpDb, err := cfg.db.GetChirpById(context.Background(), database.GetChirpByIdParams{
+		ID:       chirpId,
+		ViewerID: viewerId,
+	})
 	if err != nil {
+		deletedDb, deletedErr := cfg.db.GetDeletedChirpById(context.Background(), database.GetDeletedChirpByIdParams{
+			ID:       chirpId,
+			ViewerID: viewerId,
+		})
+		if deletedErr == nil {
+			respondWithJSON(w, http.StatusGone, tombstoneFromDb(deletedDb))
+			return
+		}
 		respondWithError(w, http.StatusNotFound, "Invalid id")
 		return
 	}
 
-	chirpJson := chirp{
-		Id:        chirpDb.ID.String(),
-		CreatedAt: chirpDb.CreatedAt.String(),
-		UpdatedAt: chirpDb.UpdatedAt.String(),
-		Body:      chirpDb.Body,
-		UserId:    chirpDb.UserID.String(),
+	chirpJson, err := cfg.chirpResponse(context.Background(), viewerId, chirpDb)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading chirp details")
+		log.Printf("Error loading chirp details: %v", err)
+		return
 	}
 
 	respondWithJSON(w, 200, chirpJson)
@@ -334,14 +488,24 @@ func (cfg *apiConfig) loginHandler(w http.ResponseWriter, req *http.Request) {
 	cfg.db.CreateRefreshToken(context.Background(), refreshTokenParams)
 
 	userJson := user{
-		Id:           userDb.ID.String(),
-		CreatedAt:    userDb.CreatedAt.String(),
-		UpdatedAt:    userDb.UpdatedAt.String(),
-		Email:        userDb.Email,
-		Token:        token,
-		RefreshToken: refreshToken,
-		IsChirpyRed:  userDb.IsChirpyRed.Bool,
+		Id:            userDb.ID.String(),
+		CreatedAt:     userDb.CreatedAt.String(),
+		UpdatedAt:     userDb.UpdatedAt.String(),
+		Email:         userDb.Email,
+		Token:         token,
+		RefreshToken:  refreshToken,
+		IsChirpyRed:   userDb.IsChirpyRed.Bool,
+		IsModerator:   userDb.IsModerator,
+		HideSensitive: userDb.HideSensitive,
+		DmPolicy:      userDb.DmPolicy,
+	}
+
+	counts, err := cfg.followCounts(context.Background(), []uuid.UUID{userDb.ID})
+	if err != nil {
+		log.Printf("Error getting follow counts: %v", err)
 	}
+	userJson.FollowerCount = counts[userDb.ID].FollowerCount
+	userJson.FollowingCount = counts[userDb.ID].FollowingCount
 
 	respondWithJSON(w, http.StatusOK, userJson)
 }
@@ -483,7 +647,10 @@ func (cfg *apiConfig) deleteChirp(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	chirp, err := cfg.db.GetChirpById(context.Background(), chirpId)
+	chirp, err := cfg.db.GetChirpById(context.Background(), database.GetChirpByIdParams{
+		ID:       chirpId,
+		ViewerID: userUniqueId,
+	})
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Chirp not found")
 		log.Printf("Error getting chirp by id: %v", err)
@@ -496,17 +663,18 @@ func (cfg *apiConfig) deleteChirp(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	deleteChirpByIdParams := database.DeleteChirpByIdParams{
+	softDeleteChirpByIdParams := database.SoftDeleteChirpByIdParams{
 		UserID: userUniqueId,
 		ID:     chirpId,
 	}
 
-	err = cfg.db.DeleteChirpById(context.Background(), deleteChirpByIdParams)
+	_, err = cfg.db.SoftDeleteChirpById(context.Background(), softDeleteChirpByIdParams)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Somethings went wrong deleting chirp")
 		log.Printf("Error deleting chirp by id: %v", err)
 		return
 	}
+	cfg.streamChirpDeleted(chirp)
 
 	respondWithJSON(w, http.StatusNoContent, nil)
 }
@@ -554,12 +722,110 @@ func (cfg *apiConfig) webhooks(w http.ResponseWriter, req *http.Request) {
 	respondWithJSON(w, http.StatusNoContent, nil)
 }
 
+// routes registers every endpoint on a new mux. It panics if two patterns
+// conflict, which TestRoutes relies on.
+func (cfg *apiConfig) routes() *http.ServeMux {
+	serveMuxplier := http.NewServeMux()
+	serveMuxplier.Handle("/app/", http.StripPrefix("/app", cfg.middlewareMetricsInc(http.FileServer(http.Dir(".")))))
+	serveMuxplier.HandleFunc("GET /api/healthz", endpointHandler)
+	serveMuxplier.HandleFunc("GET /admin/metrics", cfg.requestsCountHandler)
+	serveMuxplier.HandleFunc("POST /admin/reset", cfg.resetHandler)
+	serveMuxplier.HandleFunc("POST /api/users", cfg.createUserHandler)
+	serveMuxplier.HandleFunc("POST /api/chirps", cfg.validatePost)
+	serveMuxplier.HandleFunc("POST /api/chirps/thread", cfg.postThread)
+	serveMuxplier.HandleFunc("GET /api/chirps", cfg.getChirps)
+	serveMuxplier.HandleFunc("GET /api/chirps/{chirpID}", cfg.getChirpById)
+	serveMuxplier.HandleFunc("POST /api/login", cfg.loginHandler)
+	serveMuxplier.HandleFunc("POST /api/refresh", cfg.refreshHandler)
+	serveMuxplier.HandleFunc("POST /api/revoke", cfg.revokeHandler)
+	serveMuxplier.HandleFunc("PUT /api/users", cfg.changePassword)
+	serveMuxplier.HandleFunc("PUT /api/users/preferences", cfg.updatePreferences)
+	serveMuxplier.HandleFunc("PUT /api/users/profile", cfg.updateProfile)
+	serveMuxplier.HandleFunc("GET /api/users/recommendations", cfg.getRecommendations)
+	serveMuxplier.HandleFunc("GET /api/users/{handle}", cfg.getProfile)
+	serveMuxplier.HandleFunc("PUT /api/users/{userID}/follow", cfg.followUser)
+	serveMuxplier.HandleFunc("DELETE /api/users/{userID}/follow", cfg.unfollowUser)
+	serveMuxplier.HandleFunc("GET /api/users/{userID}/followers", cfg.getFollowers)
+	serveMuxplier.HandleFunc("GET /api/users/{userID}/following", cfg.getFollowing)
+	serveMuxplier.HandleFunc("PUT /api/users/{userID}/block", cfg.blockUser)
+	serveMuxplier.HandleFunc("DELETE /api/users/{userID}/block", cfg.unblockUser)
+	serveMuxplier.HandleFunc("PUT /api/users/{userID}/mute", cfg.muteUser)
+	serveMuxplier.HandleFunc("DELETE /api/users/{userID}/mute", cfg.unmuteUser)
+	serveMuxplier.HandleFunc("GET /api/blocks", cfg.getBlocks)
+	serveMuxplier.HandleFunc("GET /api/mutes", cfg.getMutes)
+	serveMuxplier.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.deleteChirp)
+	serveMuxplier.HandleFunc("POST /api/chirps/{chirpID}/restore", cfg.restoreChirp)
+	serveMuxplier.HandleFunc("PUT /api/chirps/{chirpID}/visibility", cfg.updateChirpVisibility)
+	serveMuxplier.HandleFunc("POST /api/chirps/{chirpID}/poll/votes", cfg.votePoll)
+	serveMuxplier.HandleFunc("PUT /api/chirps/{chirpID}/bookmark", cfg.addBookmark)
+	serveMuxplier.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", cfg.removeBookmark)
+	serveMuxplier.HandleFunc("PUT /api/chirps/{chirpID}/like", cfg.likeChirp)
+	serveMuxplier.HandleFunc("DELETE /api/chirps/{chirpID}/like", cfg.unlikeChirp)
+	serveMuxplier.HandleFunc("GET /api/bookmarks", cfg.getBookmarks)
+	serveMuxplier.HandleFunc("GET /api/timeline/home", cfg.getHomeTimeline)
+	serveMuxplier.HandleFunc("GET /api/trends", cfg.getTrends)
+	serveMuxplier.HandleFunc("GET /api/stream", cfg.getStream)
+	serveMuxplier.HandleFunc("GET /api/live", cfg.getLive)
+	serveMuxplier.HandleFunc("GET /api/lists", cfg.getLists)
+	serveMuxplier.HandleFunc("POST /api/lists", cfg.createList)
+	serveMuxplier.HandleFunc("GET /api/lists/subscribed", cfg.getSubscribedLists)
+	serveMuxplier.HandleFunc("GET /api/lists/{listID}", cfg.getList)
+	serveMuxplier.HandleFunc("PUT /api/lists/{listID}", cfg.updateList)
+	serveMuxplier.HandleFunc("DELETE /api/lists/{listID}", cfg.deleteList)
+	serveMuxplier.HandleFunc("GET /api/lists/{listID}/members", cfg.getListMembers)
+	serveMuxplier.HandleFunc("PUT /api/lists/{listID}/members/{userID}", cfg.addListMember)
+	serveMuxplier.HandleFunc("DELETE /api/lists/{listID}/members/{userID}", cfg.removeListMember)
+	serveMuxplier.HandleFunc("PUT /api/lists/{listID}/subscription", cfg.subscribeToList)
+	serveMuxplier.HandleFunc("DELETE /api/lists/{listID}/subscription", cfg.unsubscribeFromList)
+	serveMuxplier.HandleFunc("GET /api/lists/{listID}/timeline", cfg.getListTimeline)
+	serveMuxplier.HandleFunc("GET /api/notifications", cfg.getNotifications)
+	serveMuxplier.HandleFunc("POST /api/notifications/read", cfg.markAllNotificationsRead)
+	serveMuxplier.HandleFunc("POST /api/notifications/{notificationID}/read", cfg.markNotificationRead)
+	serveMuxplier.HandleFunc("GET /api/notifications/preferences", cfg.getNotificationPreferences)
+	serveMuxplier.HandleFunc("PUT /api/notifications/preferences", cfg.updateNotificationPreferences)
+	serveMuxplier.HandleFunc("GET /api/conversations", cfg.getConversations)
+	serveMuxplier.HandleFunc("POST /api/conversations", cfg.createConversation)
+	serveMuxplier.HandleFunc("GET /api/conversations/{conversationID}/messages", cfg.getMessages)
+	serveMuxplier.HandleFunc("POST /api/conversations/{conversationID}/messages", cfg.postMessage)
+	serveMuxplier.HandleFunc("POST /api/conversations/{conversationID}/read", cfg.markConversationRead)
+	serveMuxplier.HandleFunc("PUT /api/chirps/{chirpID}/pin", cfg.pinChirp)
+	serveMuxplier.HandleFunc("DELETE /api/chirps/{chirpID}/pin", cfg.unpinChirp)
+	serveMuxplier.HandleFunc("GET /api/scheduled_chirps", cfg.getScheduledChirps)
+	serveMuxplier.HandleFunc("PUT /api/scheduled_chirps/{chirpID}", cfg.updateScheduledChirp)
+	serveMuxplier.HandleFunc("DELETE /api/scheduled_chirps/{chirpID}", cfg.cancelScheduledChirp)
+	serveMuxplier.HandleFunc("POST /api/drafts", cfg.createDraft)
+	serveMuxplier.HandleFunc("GET /api/drafts", cfg.getDrafts)
+	serveMuxplier.HandleFunc("GET /api/drafts/{draftID}", cfg.getDraft)
+	serveMuxplier.HandleFunc("PUT /api/drafts/{draftID}", cfg.updateDraft)
+	serveMuxplier.HandleFunc("DELETE /api/drafts/{draftID}", cfg.deleteDraft)
+	serveMuxplier.HandleFunc("POST /api/drafts/{draftID}/publish", cfg.publishDraft)
+	serveMuxplier.HandleFunc("GET /admin/content-filter/rules", cfg.getContentFilterRules)
+	serveMuxplier.HandleFunc("PUT /admin/content-filter/rules", cfg.upsertContentFilterRule)
+	serveMuxplier.HandleFunc("DELETE /admin/content-filter/rules/{ruleID}", cfg.deleteContentFilterRule)
+	serveMuxplier.HandleFunc("POST /admin/content-filter/reload", cfg.reloadContentFilterHandler)
+	serveMuxplier.HandleFunc("GET /admin/content-filter/flags", cfg.getChirpFlags)
+	serveMuxplier.HandleFunc("GET /admin/trends/suppressed", cfg.getSuppressedTrends)
+	serveMuxplier.HandleFunc("PUT /admin/trends/suppressed/{term}", cfg.suppressTrend)
+	serveMuxplier.HandleFunc("DELETE /admin/trends/suppressed/{term}", cfg.unsuppressTrend)
+	serveMuxplier.HandleFunc("PUT /admin/moderators/{userID}", cfg.addModerator)
+	serveMuxplier.HandleFunc("DELETE /admin/moderators/{userID}", cfg.removeModerator)
+	serveMuxplier.HandleFunc("PUT /api/moderation/chirps/{chirpID}/sensitive", cfg.setChirpSensitive)
+	serveMuxplier.HandleFunc("GET /api/moderation/chirps/{chirpID}/actions", cfg.getModerationActions)
+	serveMuxplier.HandleFunc("POST /api/polka/webhooks", cfg.webhooks)
+	serveMuxplier.HandleFunc("GET /api/hashtags/{tag}/chirps", cfg.getHashtagChirps)
+	serveMuxplier.HandleFunc("POST /api/media", cfg.uploadMedia)
+	serveMuxplier.HandleFunc("GET /media/{key}", cfg.serveMedia)
+	return serveMuxplier
+}
+
 func main() {
 	godotenv.Load()
 	var apicfg apiConfig
 
 	apicfg.tokenSecret = os.Getenv("TOKEN_SECRET")
 	apicfg.polkaApiKey = os.Getenv("POLKA_KEY")
+	apicfg.adminApiKey = os.Getenv("ADMIN_KEY")
+	apicfg.filterFile = os.Getenv("CONTENT_FILTER_FILE")
 
 	dbURL := os.Getenv("DB_URL")
 	db, err := sql.Open("postgres", dbURL)
@@ -568,27 +834,62 @@ func main() {
 		return
 	}
 
+	apicfg.restoreWindow = 7 * 24 * time.Hour
+	if window := os.Getenv("CHIRP_RESTORE_WINDOW"); window != "" {
+		apicfg.restoreWindow, err = time.ParseDuration(window)
+		if err != nil {
+			log.Fatalf("Error parsing CHIRP_RESTORE_WINDOW: %v", err)
+		}
+	}
+
+	apicfg.trendsWindow = time.Hour
+	if window := os.Getenv("TRENDS_WINDOW"); window != "" {
+		apicfg.trendsWindow, err = time.ParseDuration(window)
+		if err != nil {
+			log.Fatalf("Error parsing TRENDS_WINDOW: %v", err)
+		}
+	}
+	apicfg.trendsBaseline = 7 * 24 * time.Hour
+	if baseline := os.Getenv("TRENDS_BASELINE"); baseline != "" {
+		apicfg.trendsBaseline, err = time.ParseDuration(baseline)
+		if err != nil {
+			log.Fatalf("Error parsing TRENDS_BASELINE: %v", err)
+		}
+	}
+	if apicfg.trendsWindow <= 0 || apicfg.trendsBaseline <= 0 {
+		log.Fatalf("TRENDS_WINDOW and TRENDS_BASELINE must be positive")
+	}
+
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "media"
+	}
+	blobs, err := blobstore.NewLocalStore(mediaDir)
+	if err != nil {
+		log.Fatalf("Error opening media store: %v", err)
+	}
+	apicfg.blobs = blobs
+
 	dbQueries := database.New(db)
 	apicfg.db = dbQueries
+	apicfg.dbConn = db
+	apicfg.stream = broker.New[streamEvent](streamReplaySize)
+	apicfg.live = broker.New[liveEvent](0)
+
+	if err := apicfg.reloadContentFilter(context.Background()); err != nil {
+		log.Fatalf("Error loading content filter: %v", err)
+	}
 
-	serveMuxplier := http.NewServeMux()
 	server := http.Server{
-		Handler: serveMuxplier,
+		Handler: apicfg.routes(),
 		Addr:    ":8080",
 	}
-	serveMuxplier.Handle("/app/", http.StripPrefix("/app", apicfg.middlewareMetricsInc(http.FileServer(http.Dir(".")))))
-	serveMuxplier.HandleFunc("GET /api/healthz", endpointHandler)
-	serveMuxplier.HandleFunc("GET /admin/metrics", apicfg.requestsCountHandler)
-	serveMuxplier.HandleFunc("POST /admin/reset", apicfg.resetHandler)
-	serveMuxplier.HandleFunc("POST /api/users", apicfg.createUserHandler)
-	serveMuxplier.HandleFunc("POST /api/chirps", apicfg.validatePost)
-	serveMuxplier.HandleFunc("GET /api/chirps", apicfg.getChirps)
-	serveMuxplier.HandleFunc("GET /api/chirps/{chirpID}", apicfg.getChirpById)
-	serveMuxplier.HandleFunc("POST /api/login", apicfg.loginHandler)
-	serveMuxplier.HandleFunc("POST /api/refresh", apicfg.refreshHandler)
-	serveMuxplier.HandleFunc("POST /api/revoke", apicfg.revokeHandler)
-	serveMuxplier.HandleFunc("PUT /api/users", apicfg.changePassword)
-	serveMuxplier.HandleFunc("DELETE /api/chirps/{chirpID}", apicfg.deleteChirp)
-	serveMuxplier.HandleFunc("POST /api/polka/webhooks", apicfg.webhooks)
+
+	go apicfg.runWorker(context.Background(), "purge deleted chirps", time.Hour, apicfg.purgeDeletedChirps)
+	go apicfg.runWorker(context.Background(), "publish scheduled chirps", 30*time.Second, apicfg.publishScheduledChirps)
+	go apicfg.runWorker(context.Background(), "finalize closed polls", time.Minute, apicfg.finalizeClosedPolls)
+	go apicfg.runWorker(context.Background(), "refresh trends", 5*time.Minute, apicfg.refreshTrends)
+	go apicfg.runWorker(context.Background(), "refresh recommendations", time.Hour, apicfg.refreshRecommendations)
+
 	server.ListenAndServe()
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -56,3 +57,62 @@ func TestCleanInput(t *testing.T) {
 		}
 	}
 }
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	cursor := pageCursor{
+		CreatedAt: time.Date(2025, time.March, 14, 15, 9, 26, 535897000, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	parsed, err := parseCursor(cursor.String())
+	if err != nil {
+		t.Fatalf("parseCursor failed: %v", err)
+	}
+
+	if !parsed.CreatedAt.Equal(cursor.CreatedAt) || parsed.ID != cursor.ID {
+		t.Errorf("Expected %v, got %v", cursor, parsed)
+	}
+
+	if _, err := parseCursor("not a cursor"); err == nil {
+		t.Errorf("Expected an error for an invalid cursor")
+	}
+}
+
+func TestStreamEventIdRoundTrip(t *testing.T) {
+	parsed, ok := parseStreamEventId(streamEventId(42))
+	if !ok || parsed != 42 {
+		t.Errorf("Expected 42, got %d (ok %v)", parsed, ok)
+	}
+
+	for _, id := range []string{"42", "1-42", "not an id", streamEventId(42) + "x"} {
+		if _, ok := parseStreamEventId(id); ok {
+			t.Errorf("Expected %q not to be resumable", id)
+		}
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	cfg := &apiConfig{}
+	mux := cfg.routes()
+
+	cases := []struct {
+		method  string
+		path    string
+		pattern string
+	}{
+		{"GET", "/api/chirps/abc", "GET /api/chirps/{chirpID}"},
+		{"PUT", "/api/chirps/abc/visibility", "PUT /api/chirps/{chirpID}/visibility"},
+		{"DELETE", "/api/chirps/abc/bookmark", "DELETE /api/chirps/{chirpID}/bookmark"},
+		{"DELETE", "/api/chirps/abc/pin", "DELETE /api/chirps/{chirpID}/pin"},
+		{"PUT", "/api/chirps/abc/like", "PUT /api/chirps/{chirpID}/like"},
+		{"GET", "/api/scheduled_chirps", "GET /api/scheduled_chirps"},
+		{"PUT", "/api/scheduled_chirps/abc", "PUT /api/scheduled_chirps/{chirpID}"},
+		{"DELETE", "/api/scheduled_chirps/abc", "DELETE /api/scheduled_chirps/{chirpID}"},
+	}
+	for _, c := range cases {
+		_, pattern := mux.Handler(httptest.NewRequest(c.method, c.path, nil))
+		if pattern != c.pattern {
+			t.Errorf("%s %s: expected %q, got %q", c.method, c.path, c.pattern, pattern)
+		}
+	}
+}
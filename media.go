@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/AhmettCelik/web-server/internal/blobstore"
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/AhmettCelik/web-server/internal/media"
+	"github.com/google/uuid"
+)
+
+const maxChirpMedia = 4
+
+var errMediaUnavailable = errors.New("media does not exist, belongs to another user, is already attached or is an avatar")
+
+type chirpMedia struct {
+	Id           string `json:"id"`
+	Url          string `json:"url"`
+	ThumbnailUrl string `json:"thumbnail_url"`
+	ContentType  string `json:"content_type"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+func mediaFromDb(mediaDb database.Medium) chirpMedia {
+	return chirpMedia{
+		Id:           mediaDb.ID.String(),
+		Url:          "/media/" + mediaDb.StorageKey,
+		ThumbnailUrl: "/media/" + mediaDb.ThumbnailKey,
+		ContentType:  mediaDb.ContentType,
+		Width:        int(mediaDb.Width),
+		Height:       int(mediaDb.Height),
+	}
+}
+
+func (cfg *apiConfig) uploadMedia(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating media upload: %v", err)
+		return
+	}
+
+	// Leave some room for the multipart boundaries and headers.
+	req.Body = http.MaxBytesReader(w, req.Body, media.MaxUploadSize+1<<20)
+	file, _, err := req.FormFile("file")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Missing file or upload too large")
+		log.Printf("Error reading uploaded file: %v", err)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, media.MaxUploadSize+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error reading file")
+		log.Printf("Error reading uploaded file: %v", err)
+		return
+	}
+	if len(data) > media.MaxUploadSize {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "File is too large")
+		return
+	}
+
+	img, err := media.Process(data)
+	if errors.Is(err, media.ErrUnsupportedType) {
+		respondWithError(w, http.StatusUnsupportedMediaType, "Only jpeg, png and gif images are supported")
+		return
+	}
+	if errors.Is(err, media.ErrTooLarge) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "Image dimensions are too large")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not decode image")
+		log.Printf("Error processing image: %v", err)
+		return
+	}
+
+	mediaId := uuid.New()
+	storageKey := mediaId.String() + img.Extension
+	thumbnailKey := mediaId.String() + "_thumb" + img.ThumbnailExtension
+
+	if err := cfg.blobs.Put(context.Background(), storageKey, bytes.NewReader(img.Data)); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error storing file")
+		log.Printf("Error storing media: %v", err)
+		return
+	}
+	if err := cfg.blobs.Put(context.Background(), thumbnailKey, bytes.NewReader(img.Thumbnail)); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error storing file")
+		log.Printf("Error storing thumbnail: %v", err)
+		return
+	}
+
+	mediaDb, err := cfg.db.CreateMedia(context.Background(), database.CreateMediaParams{
+		ID:           mediaId,
+		UserID:       userId,
+		ContentType:  img.ContentType,
+		StorageKey:   storageKey,
+		ThumbnailKey: thumbnailKey,
+		Width:        int32(img.Width),
+		Height:       int32(img.Height),
+		SizeBytes:    int32(len(img.Data)),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error saving media")
+		log.Printf("Error creating media: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, mediaFromDb(mediaDb))
+}
+
+// serveMedia serves stored blobs to whoever can see the chirp they are
+// attached to. Avatars are served to everyone; other media not attached yet
+// is only served to its uploader. Only avatars and media on public chirps
+// may be cached by shared caches, and not for long, since the chirp can
+// still be deleted or made private.
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, req *http.Request) {
+	key := req.PathValue("key")
+
+	viewerId, err := cfg.viewer(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	mediaDb, err := cfg.db.GetMediaByKey(context.Background(), key)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Media not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error reading media")
+		log.Printf("Error getting media %s: %v", key, err)
+		return
+	}
+
+	cacheControl := "private, max-age=3600"
+	switch {
+	case !mediaDb.ChirpID.Valid:
+		isAvatar, err := cfg.db.IsAvatarMedia(context.Background(), uuid.NullUUID{UUID: mediaDb.ID, Valid: true})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error reading media")
+			log.Printf("Error checking avatar for media %s: %v", key, err)
+			return
+		}
+		if isAvatar {
+			cacheControl = "public, max-age=3600"
+		} else if viewerId != mediaDb.UserID {
+			respondWithError(w, http.StatusNotFound, "Media not found")
+			return
+		}
+	case viewerId != mediaDb.UserID:
+		chirpDb, err := cfg.db.GetChirpById(context.Background(), database.GetChirpByIdParams{
+			ID:       mediaDb.ChirpID.UUID,
+			ViewerID: viewerId,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Media not found")
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error reading media")
+			log.Printf("Error getting chirp for media %s: %v", key, err)
+			return
+		}
+		if chirpDb.Visibility == chirpVisibilityPublic {
+			cacheControl = "public, max-age=3600"
+		}
+	}
+
+	blob, err := cfg.blobs.Get(context.Background(), key)
+	if errors.Is(err, blobstore.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Media not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error reading media")
+		log.Printf("Error reading blob %s: %v", key, err)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, req, key, time.Time{}, blob)
+}
+
+// attachChirpMedia links uploaded media to a new chirp in the order given.
+func attachChirpMedia(ctx context.Context, q *database.Queries, chirpId, userId uuid.UUID, mediaIds []uuid.UUID) error {
+	for i, mediaId := range mediaIds {
+		rows, err := q.AttachMediaToChirp(ctx, database.AttachMediaToChirpParams{
+			ChirpID:  uuid.NullUUID{UUID: chirpId, Valid: true},
+			Position: sql.NullInt32{Int32: int32(i), Valid: true},
+			ID:       mediaId,
+			UserID:   userId,
+		})
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return errMediaUnavailable
+		}
+	}
+
+	return nil
+}
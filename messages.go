@@ -0,0 +1,534 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/AhmettCelik/web-server/internal/textlen"
+	"github.com/google/uuid"
+)
+
+const (
+	dmPolicyEveryone  = "everyone"
+	dmPolicyFollowing = "following"
+)
+
+const (
+	maxConversationMembers = 10
+	maxMessageLength       = 1000
+)
+
+var (
+	errMessageEmpty    = errors.New("Message can not be empty")
+	errMessageTooLong  = errors.New("Message is too long")
+	errMessageRejected = errors.New("Message contains blocked words")
+)
+
+func validDmPolicy(policy string) bool {
+	return policy == dmPolicyEveryone || policy == dmPolicyFollowing
+}
+
+// conversationMember is one participant of a conversation. LastReadAt is the
+// member's read receipt: every message sent up to then has been seen.
+type conversationMember struct {
+	UserId     string `json:"user_id"`
+	JoinedAt   string `json:"joined_at"`
+	LastReadAt string `json:"last_read_at,omitempty"`
+}
+
+type conversation struct {
+	Id          string               `json:"id"`
+	Direct      bool                 `json:"direct"`
+	Members     []conversationMember `json:"members"`
+	UnreadCount int64                `json:"unread_count"`
+	CreatedAt   string               `json:"created_at"`
+	UpdatedAt   string               `json:"updated_at"`
+}
+
+type message struct {
+	Id             string `json:"id"`
+	ConversationId string `json:"conversation_id"`
+	SenderId       string `json:"sender_id"`
+	Body           string `json:"body"`
+	CreatedAt      string `json:"created_at"`
+}
+
+func messageFromDb(messageDb database.Message) message {
+	return message{
+		Id:             messageDb.ID.String(),
+		ConversationId: messageDb.ConversationID.String(),
+		SenderId:       messageDb.SenderID.String(),
+		Body:           messageDb.Body,
+		CreatedAt:      messageDb.CreatedAt.String(),
+	}
+}
+
+// directKey identifies the one-to-one conversation between two users no
+// matter which of them started it.
+func directKey(a, b uuid.UUID) string {
+	if a.String() > b.String() {
+		a, b = b, a
+	}
+	return a.String() + ":" + b.String()
+}
+
+// cleanMessageBody runs a message through the same content filter as chirps.
+// Messages are private, so terms that would flag a chirp for review are let
+// through as they are.
+func (cfg *apiConfig) cleanMessageBody(body string) (string, error) {
+	if strings.TrimSpace(body) == "" {
+		return "", errMessageEmpty
+	}
+	if textlen.Graphemes(body) > maxMessageLength {
+		return "", errMessageTooLong
+	}
+
+	result := cfg.contentFilter.Load().Apply(body)
+	if result.Rejected {
+		return "", errMessageRejected
+	}
+	return result.Text, nil
+}
+
+// conversationsResponse builds the JSON for conversations, loading their
+// members in one query. unread holds the caller's unread count for each
+// conversation.
+func (cfg *apiConfig) conversationsResponse(ctx context.Context, conversationsDb []database.Conversation, unread map[uuid.UUID]int64) ([]conversation, error) {
+	var ids []uuid.UUID
+	for _, conversationDb := range conversationsDb {
+		ids = append(ids, conversationDb.ID)
+	}
+
+	membersDb, err := cfg.db.GetConversationMembers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	members := map[uuid.UUID][]conversationMember{}
+	for _, memberDb := range membersDb {
+		member := conversationMember{
+			UserId:   memberDb.UserID.String(),
+			JoinedAt: memberDb.JoinedAt.String(),
+		}
+		if memberDb.LastReadAt.Valid {
+			member.LastReadAt = memberDb.LastReadAt.Time.String()
+		}
+		members[memberDb.ConversationID] = append(members[memberDb.ConversationID], member)
+	}
+
+	conversationsJson := []conversation{}
+	for _, conversationDb := range conversationsDb {
+		conversationsJson = append(conversationsJson, conversation{
+			Id:          conversationDb.ID.String(),
+			Direct:      conversationDb.DirectKey.Valid,
+			Members:     members[conversationDb.ID],
+			UnreadCount: unread[conversationDb.ID],
+			CreatedAt:   conversationDb.CreatedAt.String(),
+			UpdatedAt:   conversationDb.UpdatedAt.String(),
+		})
+	}
+	return conversationsJson, nil
+}
+
+// respondWithConversation sends a single conversation as userId sees it.
+func (cfg *apiConfig) respondWithConversation(w http.ResponseWriter, status int, userId uuid.UUID, conversationDb database.Conversation) {
+	unread, err := cfg.db.CountUnreadMessages(context.Background(), database.CountUnreadMessagesParams{
+		UserID:         userId,
+		ConversationID: conversationDb.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting conversation")
+		log.Printf("Error counting unread messages: %v", err)
+		return
+	}
+
+	conversationsJson, err := cfg.conversationsResponse(context.Background(), []database.Conversation{conversationDb},
+		map[uuid.UUID]int64{conversationDb.ID: unread})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting conversation")
+		log.Printf("Error getting conversation members: %v", err)
+		return
+	}
+
+	respondWithJSON(w, status, conversationsJson[0])
+}
+
+func (cfg *apiConfig) getConversations(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating conversations: %v", err)
+		return
+	}
+
+	limit, cursor, err := pageParams(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := cfg.db.GetConversations(context.Background(), database.GetConversationsParams{
+		UserID:          userId,
+		BeforeUpdatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		PageSize:        limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting conversations")
+		log.Printf("Error getting conversations: %v", err)
+		return
+	}
+
+	var conversationsDb []database.Conversation
+	unread := map[uuid.UUID]int64{}
+	for _, row := range rows {
+		conversationsDb = append(conversationsDb, database.Conversation{
+			ID:        row.ID,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+			DirectKey: row.DirectKey,
+		})
+		unread[row.ID] = row.UnreadCount
+	}
+
+	conversationsJson, err := cfg.conversationsResponse(context.Background(), conversationsDb, unread)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting conversations")
+		log.Printf("Error getting conversation members: %v", err)
+		return
+	}
+
+	conversations := page[conversation]{Items: conversationsJson}
+	if len(rows) == int(limit) {
+		last := rows[len(rows)-1]
+		conversations.NextCursor = pageCursor{CreatedAt: last.UpdatedAt, ID: last.ID}.String()
+	}
+
+	respondWithJSON(w, http.StatusOK, conversations)
+}
+
+// createConversation starts a conversation with one or more other users.
+// Asking for a one-to-one conversation that already exists returns it
+// instead of starting a second one. Users who only take messages from people
+// they follow, and users blocked either way, can't be added.
+func (cfg *apiConfig) createConversation(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating conversations: %v", err)
+		return
+	}
+
+	var request struct {
+		MemberIds []string `json:"member_ids"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	var others []uuid.UUID
+	seen := map[uuid.UUID]bool{userId: true}
+	for _, id := range request.MemberIds {
+		memberId, err := uuid.Parse(id)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+			return
+		}
+		if seen[memberId] {
+			continue
+		}
+		seen[memberId] = true
+		others = append(others, memberId)
+	}
+	if len(others) == 0 {
+		respondWithError(w, http.StatusBadRequest, "A conversation needs at least one other member")
+		return
+	}
+	if len(others)+1 > maxConversationMembers {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("A conversation can have at most %d members", maxConversationMembers))
+		return
+	}
+
+	restricted, err := cfg.db.CountDmRestrictedUsers(context.Background(), database.CountDmRestrictedUsersParams{
+		UserIds:  others,
+		SenderID: userId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating conversation")
+		log.Printf("Error checking message restrictions: %v", err)
+		return
+	}
+	if restricted > 0 {
+		respondWithError(w, http.StatusForbidden, "Some of these users don't accept messages from you")
+		return
+	}
+
+	directKeyDb := sql.NullString{}
+	if len(others) == 1 {
+		directKeyDb = sql.NullString{String: directKey(userId, others[0]), Valid: true}
+
+		existing, err := cfg.db.GetDirectConversation(context.Background(), directKeyDb.String)
+		if err == nil {
+			cfg.respondWithConversation(w, http.StatusOK, userId, existing)
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusInternalServerError, "Error creating conversation")
+			log.Printf("Error getting direct conversation: %v", err)
+			return
+		}
+	}
+
+	var conversationDb database.Conversation
+	err = cfg.withTx(context.Background(), func(q *database.Queries) error {
+		conversationDb, err = q.CreateConversation(context.Background(), directKeyDb)
+		if err != nil {
+			return err
+		}
+		for _, memberId := range append([]uuid.UUID{userId}, others...) {
+			if err := q.AddConversationMember(context.Background(), database.AddConversationMemberParams{
+				ConversationID: conversationDb.ID,
+				UserID:         memberId,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if isUniqueViolation(err) && directKeyDb.Valid {
+		// The other user started the same conversation at the same time.
+		existing, err := cfg.db.GetDirectConversation(context.Background(), directKeyDb.String)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error creating conversation")
+			log.Printf("Error getting direct conversation: %v", err)
+			return
+		}
+		cfg.respondWithConversation(w, http.StatusOK, userId, existing)
+		return
+	}
+	if isForeignKeyViolation(err) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating conversation")
+		log.Printf("Error creating conversation: %v", err)
+		return
+	}
+
+	cfg.respondWithConversation(w, http.StatusCreated, userId, conversationDb)
+}
+
+// conversationMembers checks that userId belongs to the conversation in the
+// request path and returns the conversation's members. Conversations the
+// user isn't part of are reported as not found, so their ids can't be
+// probed.
+func (cfg *apiConfig) conversationMembers(w http.ResponseWriter, req *http.Request, userId uuid.UUID) (uuid.UUID, []database.ConversationMember, bool) {
+	conversationId, err := uuid.Parse(req.PathValue("conversationID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return uuid.Nil, nil, false
+	}
+
+	_, err = cfg.db.GetConversationMember(context.Background(), database.GetConversationMemberParams{
+		ConversationID: conversationId,
+		UserID:         userId,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Conversation not found")
+		return uuid.Nil, nil, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting conversation")
+		log.Printf("Error getting conversation member: %v", err)
+		return uuid.Nil, nil, false
+	}
+
+	membersDb, err := cfg.db.GetConversationMembers(context.Background(), []uuid.UUID{conversationId})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting conversation")
+		log.Printf("Error getting conversation members: %v", err)
+		return uuid.Nil, nil, false
+	}
+
+	return conversationId, membersDb, true
+}
+
+func (cfg *apiConfig) getMessages(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating messages: %v", err)
+		return
+	}
+
+	conversationId, _, ok := cfg.conversationMembers(w, req, userId)
+	if !ok {
+		return
+	}
+
+	limit, cursor, err := pageParams(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	messagesDb, err := cfg.db.GetMessages(context.Background(), database.GetMessagesParams{
+		ConversationID:  conversationId,
+		BeforeCreatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		PageSize:        limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting messages")
+		log.Printf("Error getting messages: %v", err)
+		return
+	}
+
+	messages := page[message]{Items: []message{}}
+	for _, messageDb := range messagesDb {
+		messages.Items = append(messages.Items, messageFromDb(messageDb))
+	}
+	if len(messagesDb) == int(limit) {
+		last := messagesDb[len(messagesDb)-1]
+		messages.NextCursor = pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.String()
+	}
+
+	respondWithJSON(w, http.StatusOK, messages)
+}
+
+// postMessage sends a message to a conversation. In one-to-one conversations
+// the recipient's message settings and blocks are checked again on every
+// message, so changing them takes effect right away.
+func (cfg *apiConfig) postMessage(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating messages: %v", err)
+		return
+	}
+
+	conversationId, membersDb, ok := cfg.conversationMembers(w, req, userId)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	body, err := cfg.cleanMessageBody(request.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var others []uuid.UUID
+	for _, memberDb := range membersDb {
+		if memberDb.UserID != userId {
+			others = append(others, memberDb.UserID)
+		}
+	}
+
+	// Direct conversations follow the other user's current policy. Group
+	// members agreed to the group when they joined, but a block between
+	// the sender and any member still stops the sender from posting.
+	if len(membersDb) == 2 {
+		restricted, err := cfg.db.CountDmRestrictedUsers(context.Background(), database.CountDmRestrictedUsersParams{
+			UserIds:  others,
+			SenderID: userId,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error sending message")
+			log.Printf("Error checking message restrictions: %v", err)
+			return
+		}
+		if restricted > 0 {
+			respondWithError(w, http.StatusForbidden, "This user doesn't accept messages from you")
+			return
+		}
+	} else {
+		blocked, err := cfg.db.CountBlocksWithUsers(context.Background(), database.CountBlocksWithUsersParams{
+			UserIds:  others,
+			SenderID: userId,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error sending message")
+			log.Printf("Error checking message blocks: %v", err)
+			return
+		}
+		if blocked > 0 {
+			respondWithError(w, http.StatusForbidden, "You can not message this conversation because of a block")
+			return
+		}
+	}
+
+	var messageDb database.Message
+	err = cfg.withTx(context.Background(), func(q *database.Queries) error {
+		messageDb, err = q.CreateMessage(context.Background(), database.CreateMessageParams{
+			ConversationID: conversationId,
+			SenderID:       userId,
+			Body:           body,
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.TouchConversation(context.Background(), conversationId); err != nil {
+			return err
+		}
+		// Sending a message means the sender has seen everything before it.
+		return q.MarkConversationRead(context.Background(), database.MarkConversationReadParams{
+			ConversationID: conversationId,
+			UserID:         userId,
+		})
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error sending message")
+		log.Printf("Error creating message: %v", err)
+		return
+	}
+
+	cfg.publishLive(liveEvent{
+		Type:           liveMessageCreated,
+		ActorID:        userId,
+		ConversationID: conversationId,
+	}, messageFromDb(messageDb))
+	respondWithJSON(w, http.StatusCreated, messageFromDb(messageDb))
+}
+
+// markConversationRead moves the caller's read receipt up to now.
+func (cfg *apiConfig) markConversationRead(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating messages: %v", err)
+		return
+	}
+
+	conversationId, _, ok := cfg.conversationMembers(w, req, userId)
+	if !ok {
+		return
+	}
+
+	if err := cfg.db.MarkConversationRead(context.Background(), database.MarkConversationReadParams{
+		ConversationID: conversationId,
+		UserID:         userId,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating conversation")
+		log.Printf("Error marking conversation read: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
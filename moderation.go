@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	moderationActionMarkSensitive   = "mark_sensitive"
+	moderationActionUnmarkSensitive = "unmark_sensitive"
+)
+
+var errNotModerator = errors.New("user is not a moderator")
+
+type moderationAction struct {
+	Id          string `json:"id"`
+	CreatedAt   string `json:"created_at"`
+	ChirpId     string `json:"chirp_id"`
+	ModeratorId string `json:"moderator_id"`
+	Action      string `json:"action"`
+	Reason      string `json:"reason"`
+}
+
+func moderationActionFromDb(actionDb database.ModerationAction) moderationAction {
+	return moderationAction{
+		Id:          actionDb.ID.String(),
+		CreatedAt:   actionDb.CreatedAt.String(),
+		ChirpId:     actionDb.ChirpID.String(),
+		ModeratorId: actionDb.ModeratorID.String(),
+		Action:      actionDb.Action,
+		Reason:      actionDb.Reason,
+	}
+}
+
+// authenticateModerator checks the bearer token and that its user is a
+// moderator. The flag is read on every request, so removing a moderator takes
+// effect without waiting for their token to expire.
+func (cfg *apiConfig) authenticateModerator(req *http.Request) (uuid.UUID, error) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	userDb, err := cfg.db.GetUserById(context.Background(), userId)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if !userDb.IsModerator {
+		return uuid.Nil, errNotModerator
+	}
+
+	return userId, nil
+}
+
+// setChirpSensitive lets a moderator apply or remove the sensitive flag on
+// any chirp. The change and the audit entry are written together.
+func (cfg *apiConfig) setChirpSensitive(w http.ResponseWriter, req *http.Request) {
+	moderatorId, err := cfg.authenticateModerator(req)
+	if errors.Is(err, errNotModerator) {
+		respondWithError(w, http.StatusForbidden, "Permission denied")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating moderator: %v", err)
+		return
+	}
+
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	update := struct {
+		Sensitive *bool  `json:"sensitive"`
+		Reason    string `json:"reason"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&update); err != nil || update.Sensitive == nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	action := moderationActionUnmarkSensitive
+	if *update.Sensitive {
+		action = moderationActionMarkSensitive
+	}
+
+	var chirpDb database.Chirp
+	err = cfg.withTx(context.Background(), func(q *database.Queries) error {
+		var err error
+		chirpDb, err = q.SetChirpSensitive(context.Background(), database.SetChirpSensitiveParams{
+			Sensitive: *update.Sensitive,
+			ID:        chirpId,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateModerationAction(context.Background(), database.CreateModerationActionParams{
+			ChirpID:     chirpId,
+			ModeratorID: moderatorId,
+			Action:      action,
+			Reason:      update.Reason,
+		})
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating chirp")
+		log.Printf("Error setting chirp sensitive: %v", err)
+		return
+	}
+
+	chirpJson, err := cfg.chirpResponse(context.Background(), moderatorId, chirpDb)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading chirp details")
+		log.Printf("Error loading chirp details: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirpJson)
+}
+
+func (cfg *apiConfig) getModerationActions(w http.ResponseWriter, req *http.Request) {
+	_, err := cfg.authenticateModerator(req)
+	if errors.Is(err, errNotModerator) {
+		respondWithError(w, http.StatusForbidden, "Permission denied")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating moderator: %v", err)
+		return
+	}
+
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	actionsDb, err := cfg.db.GetModerationActionsForChirp(context.Background(), chirpId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting moderation actions")
+		log.Printf("Error getting moderation actions: %v", err)
+		return
+	}
+
+	actions := []moderationAction{}
+	for _, actionDb := range actionsDb {
+		actions = append(actions, moderationActionFromDb(actionDb))
+	}
+
+	respondWithJSON(w, http.StatusOK, actions)
+}
+
+func (cfg *apiConfig) addModerator(w http.ResponseWriter, req *http.Request) {
+	cfg.setModerator(w, req, true)
+}
+
+func (cfg *apiConfig) removeModerator(w http.ResponseWriter, req *http.Request) {
+	cfg.setModerator(w, req, false)
+}
+
+func (cfg *apiConfig) setModerator(w http.ResponseWriter, req *http.Request, isModerator bool) {
+	if err := cfg.authenticateAdmin(req); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid api key")
+		return
+	}
+
+	userId, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	updated, err := cfg.db.UpdateUserModerator(context.Background(), database.UpdateUserModeratorParams{
+		IsModerator: isModerator,
+		ID:          userId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating user")
+		log.Printf("Error updating moderator: %v", err)
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
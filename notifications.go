@@ -0,0 +1,383 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/AhmettCelik/web-server/internal/entities"
+	"github.com/google/uuid"
+)
+
+const (
+	notificationReply   = "reply"
+	notificationMention = "mention"
+	notificationFollow  = "follow"
+	notificationRechirp = "rechirp"
+	notificationQuote   = "quote"
+	notificationLike    = "like"
+)
+
+var notificationTypes = []string{
+	notificationReply,
+	notificationMention,
+	notificationFollow,
+	notificationRechirp,
+	notificationQuote,
+	notificationLike,
+}
+
+// domainEvent is something one user did that another user may want to hear
+// about. Events are recorded in the same transaction as the change that
+// caused them, so a rolled back chirp or follow never notifies anyone.
+type domainEvent struct {
+	Type        string
+	ActorID     uuid.UUID
+	RecipientID uuid.UUID
+	ChirpID     uuid.NullUUID
+}
+
+// groupKey decides which events are shown together, so five likes of a
+// chirp become one notification.
+func (ev domainEvent) groupKey() string {
+	if ev.ChirpID.Valid {
+		return ev.Type + ":" + ev.ChirpID.UUID.String()
+	}
+	return ev.Type
+}
+
+// recordEvent turns an event into a notification for its recipient. Events a
+// user caused themselves, turned off in their preferences, or that involve
+// someone they blocked or muted are dropped by the query.
+func recordEvent(ctx context.Context, q *database.Queries, ev domainEvent) error {
+	return q.RecordNotification(ctx, database.RecordNotificationParams{
+		RecipientID: ev.RecipientID,
+		Type:        ev.Type,
+		ChirpID:     ev.ChirpID,
+		GroupKey:    ev.groupKey(),
+		ActorID:     ev.ActorID,
+	})
+}
+
+// chirpEvents lists the events caused by a chirp being published: replies
+// notify the author of the parent chirp, rechirps and quotes the author of
+// the chirp they share, and mentions the users they name.
+func chirpEvents(ctx context.Context, q *database.Queries, chirpDb database.Chirp) ([]domainEvent, error) {
+	var events []domainEvent
+
+	addFor := func(eventType string, chirpId uuid.NullUUID) error {
+		if !chirpId.Valid {
+			return nil
+		}
+		authorId, err := q.GetChirpAuthor(ctx, chirpId.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		events = append(events, domainEvent{
+			Type:        eventType,
+			ActorID:     chirpDb.UserID,
+			RecipientID: authorId,
+			ChirpID:     chirpId,
+		})
+		return nil
+	}
+
+	if err := addFor(notificationReply, chirpDb.InReplyToID); err != nil {
+		return nil, err
+	}
+
+	switch chirpDb.Kind {
+	case chirpKindRechirp:
+		if err := addFor(notificationRechirp, chirpDb.ReferencedChirpID); err != nil {
+			return nil, err
+		}
+	case chirpKindQuote:
+		if err := addFor(notificationQuote, chirpDb.ReferencedChirpID); err != nil {
+			return nil, err
+		}
+	}
+
+	var handles []string
+	for _, entity := range entities.Parse(chirpDb.Body) {
+		if entity.Kind == entities.KindMention {
+			handles = append(handles, entity.Value)
+		}
+	}
+	if len(handles) > 0 {
+		// Users are only told about mentions in chirps they can see.
+		mentioned, err := q.GetMentionRecipients(ctx, database.GetMentionRecipientsParams{
+			ChirpID: chirpDb.ID,
+			Handles: handles,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, recipientId := range mentioned {
+			events = append(events, domainEvent{
+				Type:        notificationMention,
+				ActorID:     chirpDb.UserID,
+				RecipientID: recipientId,
+				ChirpID:     uuid.NullUUID{UUID: chirpDb.ID, Valid: true},
+			})
+		}
+	}
+
+	return events, nil
+}
+
+type notification struct {
+	Id         string   `json:"id"`
+	Type       string   `json:"type"`
+	ChirpId    string   `json:"chirp_id,omitempty"`
+	ActorIds   []string `json:"actor_ids"`
+	ActorCount int      `json:"actor_count"`
+	Summary    string   `json:"summary"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
+	Read       bool     `json:"read"`
+}
+
+type notificationsPage struct {
+	page[notification]
+	UnreadCount int64 `json:"unread_count"`
+}
+
+func notificationSummary(notificationDb database.Notification) string {
+	who := "Someone"
+	if notificationDb.ActorCount > 1 {
+		who = fmt.Sprintf("%d people", notificationDb.ActorCount)
+	}
+
+	switch notificationDb.Type {
+	case notificationReply:
+		return who + " replied to your chirp"
+	case notificationMention:
+		return who + " mentioned you"
+	case notificationFollow:
+		return who + " followed you"
+	case notificationRechirp:
+		return who + " rechirped your chirp"
+	case notificationQuote:
+		return who + " quoted your chirp"
+	case notificationLike:
+		return who + " liked your chirp"
+	}
+	return who + " interacted with you"
+}
+
+func notificationFromDb(notificationDb database.Notification) notification {
+	notificationJson := notification{
+		Id:         notificationDb.ID.String(),
+		Type:       notificationDb.Type,
+		ActorIds:   []string{},
+		ActorCount: int(notificationDb.ActorCount),
+		Summary:    notificationSummary(notificationDb),
+		CreatedAt:  notificationDb.CreatedAt.String(),
+		UpdatedAt:  notificationDb.UpdatedAt.String(),
+		Read:       notificationDb.ReadAt.Valid,
+	}
+	if notificationDb.ChirpID.Valid {
+		notificationJson.ChirpId = notificationDb.ChirpID.UUID.String()
+	}
+	for _, actorId := range notificationDb.ActorIds {
+		notificationJson.ActorIds = append(notificationJson.ActorIds, actorId.String())
+	}
+	return notificationJson
+}
+
+// getNotifications lists the user's notifications, most recently updated
+// first, along with how many are unread.
+func (cfg *apiConfig) getNotifications(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating notifications: %v", err)
+		return
+	}
+
+	limit, cursor, err := pageParams(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	notificationsDb, err := cfg.db.GetNotifications(context.Background(), database.GetNotificationsParams{
+		RecipientID:     userId,
+		BeforeUpdatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		PageSize:        limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting notifications")
+		log.Printf("Error getting notifications: %v", err)
+		return
+	}
+
+	unread, err := cfg.db.CountUnreadNotifications(context.Background(), userId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting notifications")
+		log.Printf("Error counting unread notifications: %v", err)
+		return
+	}
+
+	notifications := notificationsPage{
+		page:        page[notification]{Items: []notification{}},
+		UnreadCount: unread,
+	}
+	for _, notificationDb := range notificationsDb {
+		notifications.Items = append(notifications.Items, notificationFromDb(notificationDb))
+	}
+	if len(notificationsDb) == int(limit) {
+		last := notificationsDb[len(notificationsDb)-1]
+		notifications.NextCursor = pageCursor{CreatedAt: last.UpdatedAt, ID: last.ID}.String()
+	}
+
+	respondWithJSON(w, http.StatusOK, notifications)
+}
+
+func (cfg *apiConfig) markNotificationRead(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating notifications: %v", err)
+		return
+	}
+
+	notificationId, err := uuid.Parse(req.PathValue("notificationID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	marked, err := cfg.db.MarkNotificationRead(context.Background(), database.MarkNotificationReadParams{
+		ID:          notificationId,
+		RecipientID: userId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating notification")
+		log.Printf("Error marking notification read: %v", err)
+		return
+	}
+	if marked == 0 {
+		respondWithError(w, http.StatusNotFound, "Notification not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) markAllNotificationsRead(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating notifications: %v", err)
+		return
+	}
+
+	if _, err := cfg.db.MarkAllNotificationsRead(context.Background(), userId); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating notifications")
+		log.Printf("Error marking notifications read: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// notificationPreferences reports every notification type, with types the
+// user never changed turned on.
+func (cfg *apiConfig) notificationPreferences(ctx context.Context, userId uuid.UUID) (map[string]bool, error) {
+	preferences := map[string]bool{}
+	for _, notificationType := range notificationTypes {
+		preferences[notificationType] = true
+	}
+
+	preferencesDb, err := cfg.db.GetNotificationPreferences(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, preference := range preferencesDb {
+		preferences[preference.Type] = preference.Enabled
+	}
+	return preferences, nil
+}
+
+func (cfg *apiConfig) getNotificationPreferences(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating notifications: %v", err)
+		return
+	}
+
+	preferences, err := cfg.notificationPreferences(context.Background(), userId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting preferences")
+		log.Printf("Error getting notification preferences: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, preferences)
+}
+
+// updateNotificationPreferences turns notification types on or off. Types
+// left out of the request keep their current setting.
+func (cfg *apiConfig) updateNotificationPreferences(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating notifications: %v", err)
+		return
+	}
+
+	update := map[string]bool{}
+	if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	known := map[string]bool{}
+	for _, notificationType := range notificationTypes {
+		known[notificationType] = true
+	}
+	for notificationType := range update {
+		if !known[notificationType] {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Unknown notification type %q", notificationType))
+			return
+		}
+	}
+
+	err = cfg.withTx(context.Background(), func(q *database.Queries) error {
+		for notificationType, enabled := range update {
+			if err := q.UpsertNotificationPreference(context.Background(), database.UpsertNotificationPreferenceParams{
+				UserID:  userId,
+				Type:    notificationType,
+				Enabled: enabled,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating preferences")
+		log.Printf("Error updating notification preferences: %v", err)
+		return
+	}
+
+	preferences, err := cfg.notificationPreferences(context.Background(), userId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting preferences")
+		log.Printf("Error getting notification preferences: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, preferences)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestGroupKey(t *testing.T) {
+	chirpA := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	chirpB := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+
+	cases := []struct {
+		name      string
+		a, b      domainEvent
+		sameGroup bool
+	}{
+		{
+			name:      "rechirps of the same chirp by different users",
+			a:         domainEvent{Type: notificationRechirp, ActorID: uuid.New(), ChirpID: chirpA},
+			b:         domainEvent{Type: notificationRechirp, ActorID: uuid.New(), ChirpID: chirpA},
+			sameGroup: true,
+		},
+		{
+			name:      "rechirps of different chirps",
+			a:         domainEvent{Type: notificationRechirp, ChirpID: chirpA},
+			b:         domainEvent{Type: notificationRechirp, ChirpID: chirpB},
+			sameGroup: false,
+		},
+		{
+			name:      "likes of the same chirp by different users",
+			a:         domainEvent{Type: notificationLike, ActorID: uuid.New(), ChirpID: chirpA},
+			b:         domainEvent{Type: notificationLike, ActorID: uuid.New(), ChirpID: chirpA},
+			sameGroup: true,
+		},
+		{
+			name:      "different types on the same chirp",
+			a:         domainEvent{Type: notificationReply, ChirpID: chirpA},
+			b:         domainEvent{Type: notificationQuote, ChirpID: chirpA},
+			sameGroup: false,
+		},
+		{
+			name:      "follows have no chirp",
+			a:         domainEvent{Type: notificationFollow, ActorID: uuid.New()},
+			b:         domainEvent{Type: notificationFollow, ActorID: uuid.New()},
+			sameGroup: true,
+		},
+	}
+
+	for _, c := range cases {
+		if actual := c.a.groupKey() == c.b.groupKey(); actual != c.sameGroup {
+			t.Errorf("%s: same group = %v, expected %v", c.name, actual, c.sameGroup)
+		}
+	}
+}
+
+func TestNotificationSummary(t *testing.T) {
+	cases := []struct {
+		notificationType string
+		actorCount       int32
+		expected         string
+	}{
+		{notificationReply, 1, "Someone replied to your chirp"},
+		{notificationRechirp, 5, "5 people rechirped your chirp"},
+		{notificationFollow, 2, "2 people followed you"},
+		{notificationMention, 1, "Someone mentioned you"},
+		{notificationLike, 5, "5 people liked your chirp"},
+	}
+
+	for _, c := range cases {
+		actual := notificationSummary(database.Notification{Type: c.notificationType, ActorCount: c.actorCount})
+		if actual != c.expected {
+			t.Errorf("notificationSummary(%s, %d) = %q, expected %q", c.notificationType, c.actorCount, actual, c.expected)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor marks where a page ended. Paginated lists are ordered newest
+// first, with the id breaking ties between rows created at the same time.
+type pageCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// firstPage sorts after every row, so a query starting from it returns the
+// newest rows.
+var firstPage = pageCursor{
+	CreatedAt: time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC),
+	ID:        uuid.Max,
+}
+
+func (c pageCursor) String() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + "_" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func parseCursor(s string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, errInvalidCursor
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), "_")
+	if !ok {
+		return pageCursor{}, errInvalidCursor
+	}
+
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return pageCursor{}, errInvalidCursor
+	}
+
+	cursorId, err := uuid.Parse(id)
+	if err != nil {
+		return pageCursor{}, errInvalidCursor
+	}
+
+	// Timestamps are stored without a zone and read back as UTC, so the
+	// cursor has to go back the same way to compare equal.
+	return pageCursor{CreatedAt: time.Unix(0, unixNano).UTC(), ID: cursorId}, nil
+}
+
+// pageParams reads the limit and cursor query parameters. Without a cursor
+// the page starts at the newest row.
+func pageParams(req *http.Request) (int32, pageCursor, error) {
+	limit := defaultPageSize
+	if s := req.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return 0, pageCursor{}, errors.New("invalid limit")
+		}
+		limit = min(n, maxPageSize)
+	}
+
+	cursor := firstPage
+	if s := req.URL.Query().Get("cursor"); s != "" {
+		var err error
+		cursor, err = parseCursor(s)
+		if err != nil {
+			return 0, pageCursor{}, err
+		}
+	}
+
+	return int32(limit), cursor, nil
+}
+
+// page is one page of a paginated list. NextCursor is left out on the last
+// page.
+type page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) pinChirp(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating pin: %v", err)
+		return
+	}
+
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	// Pinning replaces any chirp pinned before. The query only matches the
+	// user's own published chirps.
+	pinned, err := cfg.db.PinChirp(context.Background(), database.PinChirpParams{
+		ChirpID: uuid.NullUUID{UUID: chirpId, Valid: true},
+		UserID:  userId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error pinning chirp")
+		log.Printf("Error pinning chirp: %v", err)
+		return
+	}
+	if pinned == 0 {
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) unpinChirp(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating unpin: %v", err)
+		return
+	}
+
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	unpinned, err := cfg.db.UnpinChirp(context.Background(), database.UnpinChirpParams{
+		ID:            userId,
+		PinnedChirpID: uuid.NullUUID{UUID: chirpId, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error unpinning chirp")
+		log.Printf("Error unpinning chirp: %v", err)
+		return
+	}
+	if unpinned == 0 {
+		respondWithError(w, http.StatusNotFound, "Chirp is not pinned")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// pinFirst moves the pinned chirp to the front of an author's chirps. A
+// pinned chirp the viewer can't see is not in the list and stays hidden.
+func pinFirst(chirpsDb []database.Chirp, pinnedId uuid.UUID) []database.Chirp {
+	for i, chirpDb := range chirpsDb {
+		if chirpDb.ID == pinnedId {
+			copy(chirpsDb[1:i+1], chirpsDb[:i])
+			chirpsDb[0] = chirpDb
+			break
+		}
+	}
+	return chirpsDb
+}
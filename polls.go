@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/AhmettCelik/web-server/internal/textlen"
+	"github.com/google/uuid"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 25
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+type pollInput struct {
+	Options  []string   `json:"options"`
+	ClosesAt *time.Time `json:"closes_at"`
+}
+
+// poll is a poll as one viewer sees it. Votes are left out until the viewer
+// has voted or the poll has closed, so early results can't sway anyone.
+type poll struct {
+	Id             string       `json:"id"`
+	ClosesAt       string       `json:"closes_at"`
+	Closed         bool         `json:"closed"`
+	ResultsVisible bool         `json:"results_visible"`
+	TotalVotes     *int         `json:"total_votes,omitempty"`
+	VotedOptionId  string       `json:"voted_option_id,omitempty"`
+	Options        []pollOption `json:"options"`
+}
+
+type pollOption struct {
+	Id    string `json:"id"`
+	Text  string `json:"text"`
+	Votes *int   `json:"votes,omitempty"`
+}
+
+type preparedPoll struct {
+	options  []string
+	closesAt time.Time
+}
+
+// preparePoll checks a poll sent with a new chirp. Options go through the
+// content filter like the chirp body does.
+func (cfg *apiConfig) preparePoll(input *pollInput, publishAt sql.NullTime) (*preparedPoll, []string, error) {
+	if len(input.Options) < minPollOptions || len(input.Options) > maxPollOptions {
+		return nil, nil, &chirpError{status: http.StatusBadRequest, msg: fmt.Sprintf("A poll needs %d to %d options", minPollOptions, maxPollOptions)}
+	}
+
+	if input.ClosesAt == nil {
+		return nil, nil, errPollWindow()
+	}
+	if err := checkPollWindow(*input.ClosesAt, publishAt); err != nil {
+		return nil, nil, err
+	}
+
+	prepared := &preparedPoll{closesAt: input.ClosesAt.Local()}
+	var flagged []string
+	for _, option := range input.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || textlen.Graphemes(option) > maxPollOptionLength {
+			return nil, nil, &chirpError{status: http.StatusBadRequest, msg: fmt.Sprintf("Poll options must be 1 to %d characters", maxPollOptionLength)}
+		}
+
+		result := cfg.contentFilter.Load().Apply(option)
+		if result.Rejected {
+			return nil, nil, &chirpError{status: http.StatusBadRequest, msg: errChirpRejected.Error()}
+		}
+		prepared.options = append(prepared.options, result.Text)
+		flagged = append(flagged, result.Flagged...)
+	}
+
+	return prepared, flagged, nil
+}
+
+func errPollWindow() *chirpError {
+	return &chirpError{status: http.StatusBadRequest, msg: fmt.Sprintf("A poll must close between %v and %v after it is published", minPollDuration, maxPollDuration)}
+}
+
+// checkPollWindow makes sure a poll published at publishAt, or now when it
+// isn't scheduled, stays open for an allowed length of time.
+func checkPollWindow(closesAt time.Time, publishAt sql.NullTime) error {
+	opensAt := time.Now()
+	if publishAt.Valid {
+		opensAt = publishAt.Time
+	}
+	if closesAt.Before(opensAt.Add(minPollDuration)) || closesAt.After(opensAt.Add(maxPollDuration)) {
+		return errPollWindow()
+	}
+	return nil
+}
+
+func insertPoll(ctx context.Context, q *database.Queries, chirpId uuid.UUID, prepared *preparedPoll) error {
+	pollDb, err := q.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:  chirpId,
+		ClosesAt: prepared.closesAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, option := range prepared.options {
+		if err := q.CreatePollOption(ctx, database.CreatePollOptionParams{
+			PollID:   pollDb.ID,
+			Position: int32(i),
+			Text:     option,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pollsForChirps loads the polls attached to chirps, keyed by chirp id, as
+// viewerId sees them. Pass uuid.Nil for an anonymous viewer. Open polls are
+// tallied live; finalized polls use the tallies stored when they closed.
+func (cfg *apiConfig) pollsForChirps(ctx context.Context, viewerId uuid.UUID, chirpIds []uuid.UUID) (map[uuid.UUID]*poll, error) {
+	polls := map[uuid.UUID]*poll{}
+
+	pollsDb, err := cfg.db.GetPollsForChirps(ctx, chirpIds)
+	if err != nil || len(pollsDb) == 0 {
+		return polls, err
+	}
+
+	var pollIds []uuid.UUID
+	for _, pollDb := range pollsDb {
+		pollIds = append(pollIds, pollDb.ID)
+	}
+
+	optionsDb, err := cfg.db.GetPollOptionsForPolls(ctx, pollIds)
+	if err != nil {
+		return nil, err
+	}
+
+	liveCounts := map[uuid.UUID]int{}
+	countsDb, err := cfg.db.GetPollVoteCounts(ctx, pollIds)
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range countsDb {
+		liveCounts[count.OptionID] = int(count.Votes)
+	}
+
+	votedFor := map[uuid.UUID]uuid.UUID{}
+	if viewerId != uuid.Nil {
+		votesDb, err := cfg.db.GetPollVotesByUser(ctx, database.GetPollVotesByUserParams{
+			UserID:  viewerId,
+			PollIds: pollIds,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, vote := range votesDb {
+			votedFor[vote.PollID] = vote.OptionID
+		}
+	}
+
+	optionsByPoll := map[uuid.UUID][]database.PollOption{}
+	for _, option := range optionsDb {
+		optionsByPoll[option.PollID] = append(optionsByPoll[option.PollID], option)
+	}
+
+	now := time.Now()
+	for _, pollDb := range pollsDb {
+		votedOption, voted := votedFor[pollDb.ID]
+		pollJson := &poll{
+			Id:       pollDb.ID.String(),
+			ClosesAt: pollDb.ClosesAt.String(),
+			Closed:   !pollDb.ClosesAt.After(now),
+			Options:  []pollOption{},
+		}
+		pollJson.ResultsVisible = voted || pollJson.Closed
+		if voted {
+			pollJson.VotedOptionId = votedOption.String()
+		}
+
+		total := 0
+		for _, option := range optionsByPoll[pollDb.ID] {
+			votes := liveCounts[option.ID]
+			if pollDb.FinalizedAt.Valid {
+				votes = int(option.VoteCount)
+			}
+			total += votes
+
+			optionJson := pollOption{Id: option.ID.String(), Text: option.Text}
+			if pollJson.ResultsVisible {
+				optionJson.Votes = &votes
+			}
+			pollJson.Options = append(pollJson.Options, optionJson)
+		}
+		if pollJson.ResultsVisible {
+			pollJson.TotalVotes = &total
+		}
+
+		polls[pollDb.ChirpID] = pollJson
+	}
+
+	return polls, nil
+}
+
+func (cfg *apiConfig) votePoll(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating: %v", err)
+		return
+	}
+
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	vote := struct {
+		OptionID string `json:"option_id"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&vote); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	optionId, err := uuid.Parse(vote.OptionID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse option id")
+		return
+	}
+
+	if _, err := cfg.db.GetChirpById(context.Background(), database.GetChirpByIdParams{
+		ID:       chirpId,
+		ViewerID: userId,
+	}); err != nil {
+		respondWithError(w, http.StatusNotFound, "Chirp not found")
+		return
+	}
+
+	pollDb, err := cfg.db.GetPollByChirpId(context.Background(), chirpId)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Chirp has no poll")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting poll")
+		log.Printf("Error getting poll: %v", err)
+		return
+	}
+
+	inserted, err := cfg.db.CreatePollVote(context.Background(), database.CreatePollVoteParams{
+		UserID:   userId,
+		PollID:   pollDb.ID,
+		OptionID: optionId,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Already voted")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error saving vote")
+		log.Printf("Error creating poll vote: %v", err)
+		return
+	}
+	if inserted == 0 {
+		if !pollDb.ClosesAt.After(time.Now()) {
+			respondWithError(w, http.StatusConflict, "Poll is closed")
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Option does not belong to this poll")
+		return
+	}
+
+	polls, err := cfg.pollsForChirps(context.Background(), userId, []uuid.UUID{chirpId})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading poll")
+		log.Printf("Error loading poll: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, polls[chirpId])
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/google/uuid"
+)
+
+type preferences struct {
+	HideSensitive bool   `json:"hide_sensitive"`
+	DmPolicy      string `json:"dm_policy"`
+}
+
+// updatePreferences changes the user's settings. Fields left out of the
+// request keep their current value.
+func (cfg *apiConfig) updatePreferences(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating preferences: %v", err)
+		return
+	}
+
+	userDb, err := cfg.db.GetUserById(context.Background(), userId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting preferences")
+		log.Printf("Error getting user: %v", err)
+		return
+	}
+
+	update := preferences{HideSensitive: userDb.HideSensitive, DmPolicy: userDb.DmPolicy}
+	if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if !validDmPolicy(update.DmPolicy) {
+		respondWithError(w, http.StatusBadRequest, "dm_policy must be everyone or following")
+		return
+	}
+
+	userDb, err = cfg.db.UpdateUserPreferences(context.Background(), database.UpdateUserPreferencesParams{
+		HideSensitive: update.HideSensitive,
+		DmPolicy:      update.DmPolicy,
+		ID:            userId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating preferences")
+		log.Printf("Error updating preferences: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, preferences{HideSensitive: userDb.HideSensitive, DmPolicy: userDb.DmPolicy})
+}
+
+func isSensitive(chirpJson chirp) bool {
+	if chirpJson.Sensitive || chirpJson.ContentWarning != "" {
+		return true
+	}
+	return chirpJson.ReferencedChirp != nil && isSensitive(*chirpJson.ReferencedChirp)
+}
+
+// filterSensitive applies the viewer's preference for flagged content to a
+// list of chirps. Chirps always carry their content warning and sensitive
+// flag; viewers who asked to hide them don't get them at all. Single chirps
+// fetched by id are never hidden, since the viewer asked for that one.
+func (cfg *apiConfig) filterSensitive(ctx context.Context, viewerId uuid.UUID, chirpsJson []chirp) ([]chirp, error) {
+	if viewerId == uuid.Nil {
+		return chirpsJson, nil
+	}
+
+	userDb, err := cfg.db.GetUserById(ctx, viewerId)
+	if err != nil {
+		return nil, err
+	}
+	if !userDb.HideSensitive {
+		return chirpsJson, nil
+	}
+
+	var filtered []chirp
+	for _, chirpJson := range chirpsJson {
+		if !isSensitive(chirpJson) {
+			filtered = append(filtered, chirpJson)
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/AhmettCelik/web-server/internal/textlen"
+	"github.com/google/uuid"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+)
+
+// Handles use the same characters mentions are parsed with, so every handle
+// can be mentioned.
+var handlePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,15}$`)
+
+// reservedHandles would be shadowed by fixed routes under /api/users. Keys
+// are lower case, since handles are unique regardless of case.
+var reservedHandles = map[string]bool{
+	"preferences":     true,
+	"profile":         true,
+	"recommendations": true,
+}
+
+var (
+	errInvalidHandle = errors.New("Handle must be 3 to 15 letters, numbers or underscores")
+	errHandleTaken   = errors.New("Handle is already taken")
+)
+
+// author is the compact form of a user embedded in chirps.
+type author struct {
+	Id          string `json:"id"`
+	Handle      string `json:"handle,omitempty"`
+	DisplayName string `json:"display_name"`
+	AvatarUrl   string `json:"avatar_url,omitempty"`
+}
+
+// profile is a user as anyone may see them. Unlike user it never carries the
+// email address.
+type profile struct {
+	author
+	Bio            string `json:"bio"`
+	CreatedAt      string `json:"created_at"`
+	FollowerCount  int64  `json:"follower_count"`
+	FollowingCount int64  `json:"following_count"`
+}
+
+func authorFromDb(profileDb database.GetUserProfilesRow) author {
+	authorJson := author{
+		Id:          profileDb.ID.String(),
+		Handle:      profileDb.Handle.String,
+		DisplayName: profileDb.DisplayName,
+	}
+	if profileDb.AvatarKey.Valid {
+		authorJson.AvatarUrl = "/media/" + profileDb.AvatarKey.String
+	}
+	return authorJson
+}
+
+// userProfiles loads the public profile of each user, keyed by user id.
+func (cfg *apiConfig) userProfiles(ctx context.Context, userIds []uuid.UUID) (map[uuid.UUID]database.GetUserProfilesRow, error) {
+	profiles := map[uuid.UUID]database.GetUserProfilesRow{}
+	if len(userIds) == 0 {
+		return profiles, nil
+	}
+
+	profilesDb, err := cfg.db.GetUserProfiles(ctx, userIds)
+	if err != nil {
+		return nil, err
+	}
+	for _, profileDb := range profilesDb {
+		profiles[profileDb.ID] = profileDb
+	}
+	return profiles, nil
+}
+
+// profileResponse builds the public profile of a user, with follow counts.
+func (cfg *apiConfig) profileResponse(ctx context.Context, userId uuid.UUID) (profile, error) {
+	profiles, err := cfg.userProfiles(ctx, []uuid.UUID{userId})
+	if err != nil {
+		return profile{}, err
+	}
+	profileDb, ok := profiles[userId]
+	if !ok {
+		return profile{}, sql.ErrNoRows
+	}
+
+	counts, err := cfg.followCounts(ctx, []uuid.UUID{userId})
+	if err != nil {
+		return profile{}, err
+	}
+
+	return profile{
+		author:         authorFromDb(profileDb),
+		Bio:            profileDb.Bio,
+		CreatedAt:      profileDb.CreatedAt.String(),
+		FollowerCount:  counts[userId].FollowerCount,
+		FollowingCount: counts[userId].FollowingCount,
+	}, nil
+}
+
+// cleanProfileText checks short text users describe themselves or their
+// lists with, such as a bio, against its length limit and the content
+// filter. It returns the text with masked words replaced.
+func (cfg *apiConfig) cleanProfileText(field, text string, limit int) (string, error) {
+	if textlen.Graphemes(text) > limit {
+		return "", fmt.Errorf("%s can be at most %d characters", field, limit)
+	}
+
+	result := cfg.contentFilter.Load().Apply(text)
+	if result.Rejected {
+		return "", fmt.Errorf("%s contains blocked words", field)
+	}
+	return result.Text, nil
+}
+
+func (cfg *apiConfig) getProfile(w http.ResponseWriter, req *http.Request) {
+	viewerId, err := cfg.viewer(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	userDb, err := cfg.db.GetUserByHandle(context.Background(), req.PathValue("handle"))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting user")
+		log.Printf("Error getting user by handle: %v", err)
+		return
+	}
+
+	// Users who blocked each other don't see each other's profiles either.
+	blocked, err := cfg.db.IsBlockedEitherWay(context.Background(), database.IsBlockedEitherWayParams{
+		UserID:  viewerId,
+		OtherID: userDb.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting user")
+		log.Printf("Error checking blocks: %v", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	profileJson, err := cfg.profileResponse(context.Background(), userDb.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting user")
+		log.Printf("Error getting profile: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, profileJson)
+}
+
+// updateProfile changes the caller's handle, display name, bio and avatar.
+// Fields left out of the request keep their current value; an empty
+// avatar_media_id removes the avatar. The avatar must be an image the user
+// uploaded through POST /api/media and hasn't attached to a chirp.
+func (cfg *apiConfig) updateProfile(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating profile: %v", err)
+		return
+	}
+
+	userDb, err := cfg.db.GetUserById(context.Background(), userId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting user")
+		log.Printf("Error getting user: %v", err)
+		return
+	}
+
+	update := struct {
+		Handle        string `json:"handle"`
+		DisplayName   string `json:"display_name"`
+		Bio           string `json:"bio"`
+		AvatarMediaId string `json:"avatar_media_id"`
+	}{
+		Handle:      userDb.Handle.String,
+		DisplayName: userDb.DisplayName,
+		Bio:         userDb.Bio,
+	}
+	if userDb.AvatarMediaID.Valid {
+		update.AvatarMediaId = userDb.AvatarMediaID.UUID.String()
+	}
+	if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	params := database.UpdateUserProfileParams{ID: userId}
+
+	if update.Handle != "" {
+		if !handlePattern.MatchString(update.Handle) || reservedHandles[strings.ToLower(update.Handle)] {
+			respondWithError(w, http.StatusBadRequest, errInvalidHandle.Error())
+			return
+		}
+		if cfg.contentFilter.Load().Apply(update.Handle).Rejected {
+			respondWithError(w, http.StatusBadRequest, "Handle contains blocked words")
+			return
+		}
+		params.Handle = sql.NullString{String: update.Handle, Valid: true}
+	}
+
+	params.DisplayName, err = cfg.cleanProfileText("Display name", update.DisplayName, maxDisplayNameLength)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	params.Bio, err = cfg.cleanProfileText("Bio", update.Bio, maxBioLength)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if update.AvatarMediaId != "" {
+		avatarId, err := uuid.Parse(update.AvatarMediaId)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+			return
+		}
+		params.AvatarMediaID = uuid.NullUUID{UUID: avatarId, Valid: true}
+	}
+
+	_, err = cfg.db.UpdateUserProfile(context.Background(), params)
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, errHandleTaken.Error())
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusBadRequest, "Avatar must be an image you uploaded and have not attached to a chirp")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating profile")
+		log.Printf("Error updating profile: %v", err)
+		return
+	}
+
+	profileJson, err := cfg.profileResponse(context.Background(), userId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting user")
+		log.Printf("Error getting profile: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, profileJson)
+}
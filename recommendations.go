@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	recommendationsPerUser      = 50
+	defaultRecommendationsLimit = 10
+	recommendationHashtagWindow = 30 * 24 * time.Hour
+	recommendationActiveWindow  = 7 * 24 * time.Hour
+)
+
+// Being followed by people the user follows counts for the most. Shared
+// hashtags count less, and recent activity only breaks ties between
+// otherwise similar accounts, since it can't make someone a candidate alone.
+const (
+	mutualFollowWeight  = 3.0
+	sharedHashtagWeight = 1.0
+	activityWeight      = 0.5
+)
+
+type recommendation struct {
+	User           author  `json:"user"`
+	Score          float64 `json:"score"`
+	MutualFollows  int     `json:"mutual_follows"`
+	SharedHashtags int     `json:"shared_hashtags"`
+	RecentChirps   int     `json:"recent_chirps"`
+}
+
+// refreshRecommendations recomputes who-to-follow candidates for every user.
+// The table is replaced in one transaction, so readers see either the old or
+// the new recommendations, never a mix.
+func (cfg *apiConfig) refreshRecommendations(ctx context.Context) error {
+	now := time.Now()
+
+	var computed int64
+	err := cfg.withTx(ctx, func(q *database.Queries) error {
+		if err := q.DeleteRecommendations(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		computed, err = q.ComputeRecommendations(ctx, database.ComputeRecommendationsParams{
+			HashtagsSince:  now.Add(-recommendationHashtagWindow),
+			ActiveSince:    now.Add(-recommendationActiveWindow),
+			MutualWeight:   mutualFollowWeight,
+			HashtagWeight:  sharedHashtagWeight,
+			ActivityWeight: activityWeight,
+			PerUser:        recommendationsPerUser,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Computed %d follow recommendations", computed)
+	return nil
+}
+
+// getRecommendations reads the caller's precomputed recommendations. Accounts
+// the caller followed, blocked or muted since the last batch are filtered out
+// as they are read.
+func (cfg *apiConfig) getRecommendations(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating recommendations: %v", err)
+		return
+	}
+
+	limit := defaultRecommendationsLimit
+	if s := req.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			respondWithError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = min(n, recommendationsPerUser)
+	}
+
+	recommendationsDb, err := cfg.db.GetRecommendations(context.Background(), database.GetRecommendationsParams{
+		UserID:   userId,
+		PageSize: int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting recommendations")
+		log.Printf("Error getting recommendations: %v", err)
+		return
+	}
+
+	var candidateIds []uuid.UUID
+	for _, recommendationDb := range recommendationsDb {
+		candidateIds = append(candidateIds, recommendationDb.CandidateID)
+	}
+	profiles, err := cfg.userProfiles(context.Background(), candidateIds)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting recommendations")
+		log.Printf("Error getting profiles: %v", err)
+		return
+	}
+
+	recommendations := []recommendation{}
+	for _, recommendationDb := range recommendationsDb {
+		profileDb, ok := profiles[recommendationDb.CandidateID]
+		if !ok {
+			continue
+		}
+		recommendations = append(recommendations, recommendation{
+			User:           authorFromDb(profileDb),
+			Score:          recommendationDb.Score,
+			MutualFollows:  int(recommendationDb.MutualFollows),
+			SharedHashtags: int(recommendationDb.SharedHashtags),
+			RecentChirps:   int(recommendationDb.RecentChirps),
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, recommendations)
+}
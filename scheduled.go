@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/AhmettCelik/web-server/internal/entities"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) getScheduledChirps(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating: %v", err)
+		return
+	}
+
+	chirpsDb, err := cfg.db.GetScheduledChirpsForUser(context.Background(), userId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting scheduled chirps")
+		log.Printf("Error getting scheduled chirps: %v", err)
+		return
+	}
+
+	chirpsJson, err := cfg.chirpsResponse(context.Background(), userId, chirpsDb)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading chirp details")
+		log.Printf("Error loading chirp details: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirpsJson)
+}
+
+// updateScheduledChirp changes the body or publish time of a chirp that has
+// not been published yet. Omitted fields are left as they are. The result
+// goes through the same body, quote and poll checks as a new chirp.
+func (cfg *apiConfig) updateScheduledChirp(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating: %v", err)
+		return
+	}
+
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	update := struct {
+		Body      *string    `json:"body"`
+		PublishAt *time.Time `json:"publish_at"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	existing, err := cfg.db.GetScheduledChirp(context.Background(), database.GetScheduledChirpParams{
+		ID:     chirpId,
+		UserID: userId,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Scheduled chirp not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting scheduled chirp")
+		log.Printf("Error getting scheduled chirp: %v", err)
+		return
+	}
+
+	params := database.UpdateScheduledChirpParams{
+		Body:      existing.Body,
+		PublishAt: existing.PublishAt,
+		ID:        existing.ID,
+		UserID:    userId,
+	}
+
+	var flagged []string
+	if update.Body != nil {
+		if existing.Kind == chirpKindRechirp {
+			respondWithError(w, http.StatusBadRequest, "Rechirps can not have a body")
+			return
+		}
+		limit, err := cfg.chirpLengthLimit(context.Background(), userId)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error getting user")
+			log.Printf("Error getting chirp length limit: %v", err)
+			return
+		}
+
+		if existing.Kind == chirpKindQuote && *update.Body == "" {
+			respondWithError(w, http.StatusBadRequest, "Quote chirps need a body")
+			return
+		}
+
+		params.Body, flagged, err = cfg.cleanChirpBody(*update.Body, limit)
+		var chirpErr *chirpError
+		if errors.As(err, &chirpErr) {
+			respondWithChirpError(w, chirpErr)
+			return
+		}
+	}
+
+	if update.PublishAt != nil {
+		if !update.PublishAt.After(time.Now()) {
+			respondWithError(w, http.StatusBadRequest, "publish_at must be in the future")
+			return
+		}
+		params.PublishAt = sql.NullTime{Time: update.PublishAt.Local(), Valid: true}
+	}
+
+	pollDb, err := cfg.db.GetPollByChirpId(context.Background(), existing.ID)
+	hasPoll := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Error getting scheduled chirp")
+		log.Printf("Error getting poll: %v", err)
+		return
+	}
+	if hasPoll {
+		var chirpErr *chirpError
+		if err := checkPollWindow(pollDb.ClosesAt, params.PublishAt); errors.As(err, &chirpErr) {
+			respondWithChirpError(w, chirpErr)
+			return
+		}
+	}
+
+	// Flags are stored per chirp, not per field, so a new body means
+	// flagging the content warning and poll options again as well.
+	if params.Body != existing.Body {
+		flagged = append(flagged, cfg.contentFilter.Load().Apply(existing.ContentWarning).Flagged...)
+		if hasPoll {
+			optionsDb, err := cfg.db.GetPollOptionsForPolls(context.Background(), []uuid.UUID{pollDb.ID})
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Error getting scheduled chirp")
+				log.Printf("Error getting poll options: %v", err)
+				return
+			}
+			for _, optionDb := range optionsDb {
+				flagged = append(flagged, cfg.contentFilter.Load().Apply(optionDb.Text).Flagged...)
+			}
+		}
+	}
+
+	var chirpDb database.Chirp
+	err = cfg.withTx(context.Background(), func(q *database.Queries) error {
+		chirpDb, err = q.UpdateScheduledChirp(context.Background(), params)
+		if err != nil {
+			return err
+		}
+		if params.Body == existing.Body {
+			return nil
+		}
+		if err := q.DeleteEntitiesForChirp(context.Background(), chirpDb.ID); err != nil {
+			return err
+		}
+		if err := q.DeleteChirpFlags(context.Background(), chirpDb.ID); err != nil {
+			return err
+		}
+		if err := saveChirpFlags(context.Background(), q, chirpDb.ID, flagged); err != nil {
+			return err
+		}
+		return saveChirpEntities(context.Background(), q, chirpDb.ID, entities.Parse(params.Body))
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "Chirp has already been published")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating scheduled chirp")
+		log.Printf("Error updating scheduled chirp: %v", err)
+		return
+	}
+
+	chirpJson, err := cfg.chirpResponse(context.Background(), userId, chirpDb)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading chirp details")
+		log.Printf("Error loading chirp details: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirpJson)
+}
+
+func (cfg *apiConfig) cancelScheduledChirp(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating: %v", err)
+		return
+	}
+
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+		return
+	}
+
+	deleted, err := cfg.db.DeleteScheduledChirp(context.Background(), database.DeleteScheduledChirpParams{
+		ID:     chirpId,
+		UserID: userId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error cancelling scheduled chirp")
+		log.Printf("Error deleting scheduled chirp: %v", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Scheduled chirp not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
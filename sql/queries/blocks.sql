@@ -0,0 +1,43 @@
+-- name: CreateBlock :exec
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBlock :execrows
+DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: GetBlocks :many
+SELECT * FROM blocks
+WHERE blocker_id = @blocker_id
+AND (created_at, blocked_id) < (@before_created_at::timestamp, @before_id::uuid)
+ORDER BY created_at DESC, blocked_id DESC
+LIMIT @page_size;
+
+-- name: CreateMute :exec
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteMute :execrows
+DELETE FROM mutes WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: GetMutes :many
+SELECT * FROM mutes
+WHERE muter_id = @muter_id
+AND (created_at, muted_id) < (@before_created_at::timestamp, @before_id::uuid)
+ORDER BY created_at DESC, muted_id DESC
+LIMIT @page_size;
+
+-- name: IsBlockedEitherWay :one
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = @user_id AND blocks.blocked_id = @other_id
+    OR blocks.blocker_id = @other_id AND blocks.blocked_id = @user_id
+);
+
+-- name: GetHiddenAuthors :many
+SELECT blocked_id FROM blocks WHERE blocker_id = @user_id
+UNION
+SELECT blocker_id FROM blocks WHERE blocked_id = @user_id
+UNION
+SELECT muted_id FROM mutes WHERE muter_id = @user_id;
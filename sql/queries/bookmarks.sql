@@ -0,0 +1,28 @@
+-- name: CreateBookmark :exec
+INSERT INTO bookmarks (user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBookmark :exec
+DELETE FROM bookmarks WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetBookmarksForUser :many
+SELECT b.* FROM bookmarks b
+JOIN chirps c ON c.id = b.chirp_id
+WHERE b.user_id = @user_id
+AND c.deleted_at IS NULL AND NOT c.scheduled
+AND (
+    c.visibility = 'public' OR c.user_id = @user_id
+    OR (c.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = @user_id AND follows.followee_id = c.user_id
+    ))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = c.user_id AND blocks.blocked_id = @user_id
+    OR blocks.blocker_id = @user_id AND blocks.blocked_id = c.user_id
+)
+AND (b.created_at, b.chirp_id) < (@before_created_at::timestamp, @before_id::uuid)
+ORDER BY b.created_at DESC, b.chirp_id DESC
+LIMIT @page_size;
@@ -1,23 +1,150 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, kind, referenced_chirp_id, scheduled, publish_at, visibility, content_warning, sensitive, in_reply_to_id)
 VALUES (
     gen_random_uuid(),
+    COALESCE(sqlc.narg('created_at')::timestamp, NOW()),
     NOW(),
-    NOW(),
-    $1,
-    $2
+    @body,
+    @user_id,
+    @kind,
+    @referenced_chirp_id,
+    @scheduled,
+    @publish_at,
+    @visibility,
+    @content_warning,
+    @sensitive,
+    @in_reply_to_id
 )
 RETURNING *;
 
 -- name: GetChirps :many
-SELECT * FROM chirps ORDER BY created_at;
+SELECT * FROM chirps
+WHERE deleted_at IS NULL AND NOT scheduled
+AND (
+    visibility = 'public' OR user_id = @viewer_id
+    OR (visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = @viewer_id AND follows.followee_id = chirps.user_id
+    ))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = @viewer_id
+    OR blocks.blocker_id = @viewer_id AND blocks.blocked_id = chirps.user_id
+)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = @viewer_id AND mutes.muted_id = chirps.user_id
+)
+ORDER BY created_at;
 
 -- name: GetChirpsForUserID :many
-SELECT * FROM chirps WHERE user_id=$1 ORDER BY created_at;
+SELECT * FROM chirps
+WHERE user_id = @user_id AND deleted_at IS NULL AND NOT scheduled
+AND (
+    visibility = 'public' OR user_id = @viewer_id
+    OR (visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = @viewer_id AND follows.followee_id = chirps.user_id
+    ))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = @viewer_id
+    OR blocks.blocker_id = @viewer_id AND blocks.blocked_id = chirps.user_id
+)
+ORDER BY created_at;
 
 -- name: GetChirpById :one
-SELECT * FROM chirps WHERE id=$1;
+SELECT * FROM chirps
+WHERE id = @id AND deleted_at IS NULL AND NOT scheduled
+AND (
+    visibility = 'public' OR user_id = @viewer_id
+    OR (visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = @viewer_id AND follows.followee_id = chirps.user_id
+    ))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = @viewer_id
+    OR blocks.blocker_id = @viewer_id AND blocks.blocked_id = chirps.user_id
+);
+
+-- name: GetChirpsByIds :many
+SELECT * FROM chirps
+WHERE id = ANY(@ids::uuid[]) AND NOT scheduled
+AND (
+    visibility = 'public' OR user_id = @viewer_id
+    OR (visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = @viewer_id AND follows.followee_id = chirps.user_id
+    ))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = @viewer_id
+    OR blocks.blocker_id = @viewer_id AND blocks.blocked_id = chirps.user_id
+);
+
+-- name: GetDeletedChirpById :one
+SELECT * FROM chirps
+WHERE id = @id AND deleted_at IS NOT NULL
+AND (
+    visibility = 'public' OR user_id = @viewer_id
+    OR (visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = @viewer_id AND follows.followee_id = chirps.user_id
+    ))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = @viewer_id
+    OR blocks.blocker_id = @viewer_id AND blocks.blocked_id = chirps.user_id
+);
+
+-- name: SoftDeleteChirpById :execrows
+UPDATE chirps SET deleted_at = NOW(), updated_at = NOW()
+WHERE chirps.id=$1 AND chirps.user_id=$2 AND deleted_at IS NULL;
 
--- name: DeleteChirpById :exec
+-- name: RestoreChirp :one
+UPDATE chirps SET deleted_at = NULL, updated_at = NOW()
+WHERE id = @id AND user_id = @user_id AND deleted_at > @deleted_after::timestamp
+RETURNING *;
+
+-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps WHERE deleted_at < @deleted_before::timestamp;
+
+-- name: GetScheduledChirpsForUser :many
+SELECT * FROM chirps
+WHERE user_id=$1 AND scheduled AND deleted_at IS NULL
+ORDER BY publish_at;
+
+-- name: GetScheduledChirp :one
+SELECT * FROM chirps
+WHERE id=$1 AND user_id=$2 AND scheduled AND deleted_at IS NULL;
+
+-- name: UpdateScheduledChirp :one
+UPDATE chirps SET body = $1, publish_at = $2, updated_at = NOW()
+WHERE id = $3 AND user_id = $4 AND scheduled
+RETURNING *;
+
+-- name: DeleteScheduledChirp :execrows
 DELETE FROM chirps
-WHERE chirps.id=$1 AND chirps.user_id=$2;
+WHERE id = $1 AND user_id = $2 AND scheduled;
+
+-- name: GetDueChirpIds :many
+SELECT id FROM chirps
+WHERE scheduled AND publish_at <= NOW() AND deleted_at IS NULL
+ORDER BY publish_at ASC
+LIMIT $1;
+
+-- name: PublishDueChirp :one
+UPDATE chirps SET scheduled = FALSE, created_at = publish_at, updated_at = NOW()
+WHERE id = $1 AND scheduled AND publish_at <= NOW() AND deleted_at IS NULL
+RETURNING *;
+
+-- name: UpdateChirpVisibility :one
+UPDATE chirps SET visibility = $1, updated_at = NOW()
+WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL
+RETURNING *;
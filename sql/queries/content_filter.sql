@@ -0,0 +1,31 @@
+-- name: GetContentFilterRules :many
+SELECT * FROM content_filter_rules ORDER BY term;
+
+-- name: UpsertContentFilterRule :one
+INSERT INTO content_filter_rules (id, created_at, term, action)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2
+)
+ON CONFLICT (term) DO UPDATE SET action = EXCLUDED.action
+RETURNING *;
+
+-- name: DeleteContentFilterRule :execrows
+DELETE FROM content_filter_rules WHERE id = $1;
+
+-- name: CreateChirpFlag :exec
+INSERT INTO chirp_flags (id, created_at, chirp_id, term)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2
+);
+
+-- name: DeleteChirpFlags :exec
+DELETE FROM chirp_flags WHERE chirp_id = $1;
+
+-- name: GetChirpFlags :many
+SELECT * FROM chirp_flags ORDER BY created_at DESC LIMIT $1;
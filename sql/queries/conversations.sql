@@ -0,0 +1,76 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (id, created_at, updated_at, direct_key)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1)
+RETURNING *;
+
+-- name: GetDirectConversation :one
+SELECT * FROM conversations WHERE direct_key = $1;
+
+-- name: AddConversationMember :exec
+INSERT INTO conversation_members (conversation_id, user_id, joined_at)
+VALUES ($1, $2, NOW());
+
+-- name: GetConversationMember :one
+SELECT * FROM conversation_members WHERE conversation_id = $1 AND user_id = $2;
+
+-- name: GetConversationMembers :many
+SELECT * FROM conversation_members
+WHERE conversation_id = ANY(@conversation_ids::uuid[])
+ORDER BY joined_at ASC, user_id ASC;
+
+-- name: GetConversations :many
+SELECT c.*, (
+    SELECT COUNT(*) FROM messages m
+    WHERE m.conversation_id = c.id AND m.sender_id <> @user_id
+    AND (cm.last_read_at IS NULL OR m.created_at > cm.last_read_at)
+) AS unread_count
+FROM conversations c
+JOIN conversation_members cm ON cm.conversation_id = c.id AND cm.user_id = @user_id
+WHERE (c.updated_at, c.id) < (@before_updated_at::timestamp, @before_id::uuid)
+ORDER BY c.updated_at DESC, c.id DESC
+LIMIT @page_size;
+
+-- name: CountDmRestrictedUsers :one
+SELECT COUNT(*) FROM users u
+WHERE u.id = ANY(@user_ids::uuid[])
+AND (
+    (u.dm_policy = 'following' AND NOT EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = u.id AND follows.followee_id = @sender_id
+    ))
+    OR EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocks.blocker_id = u.id AND blocks.blocked_id = @sender_id
+        OR blocks.blocker_id = @sender_id AND blocks.blocked_id = u.id
+    )
+);
+
+-- name: CountBlocksWithUsers :one
+SELECT COUNT(*) FROM blocks
+WHERE blocks.blocker_id = ANY(@user_ids::uuid[]) AND blocks.blocked_id = @sender_id
+OR blocks.blocker_id = @sender_id AND blocks.blocked_id = ANY(@user_ids::uuid[]);
+
+-- name: CreateMessage :one
+INSERT INTO messages (id, conversation_id, sender_id, body, created_at)
+VALUES (gen_random_uuid(), $1, $2, $3, NOW())
+RETURNING *;
+
+-- name: GetMessages :many
+SELECT * FROM messages
+WHERE conversation_id = @conversation_id
+AND (created_at, id) < (@before_created_at::timestamp, @before_id::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT @page_size;
+
+-- name: CountUnreadMessages :one
+SELECT COUNT(*) FROM messages m
+JOIN conversation_members cm ON cm.conversation_id = m.conversation_id AND cm.user_id = @user_id
+WHERE m.conversation_id = @conversation_id AND m.sender_id <> @user_id
+AND (cm.last_read_at IS NULL OR m.created_at > cm.last_read_at);
+
+-- name: TouchConversation :exec
+UPDATE conversations SET updated_at = NOW() WHERE id = $1;
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_members SET last_read_at = NOW()
+WHERE conversation_id = $1 AND user_id = $2;
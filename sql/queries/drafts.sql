@@ -0,0 +1,51 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (
+    id, created_at, updated_at, user_id, body, kind, referenced_chirp_id, media_ids,
+    in_reply_to_id, publish_at, visibility, content_warning, sensitive, has_poll, poll_options, poll_closes_at
+)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13
+)
+RETURNING *;
+
+-- name: GetDraftsForUser :many
+SELECT * FROM drafts WHERE user_id = $1 ORDER BY updated_at DESC;
+
+-- name: GetDraft :one
+SELECT * FROM drafts WHERE id = $1 AND user_id = $2;
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET body = $1,
+    kind = $2,
+    referenced_chirp_id = $3,
+    media_ids = $4,
+    in_reply_to_id = $5,
+    publish_at = $6,
+    visibility = $7,
+    content_warning = $8,
+    sensitive = $9,
+    has_poll = $10,
+    poll_options = $11,
+    poll_closes_at = $12,
+    updated_at = NOW()
+WHERE id = $13 AND user_id = $14
+RETURNING *;
+
+-- name: DeleteDraft :execrows
+DELETE FROM drafts WHERE id = $1 AND user_id = $2;
@@ -0,0 +1,55 @@
+-- name: UpsertHashtag :one
+INSERT INTO hashtags (id, tag)
+VALUES (
+    gen_random_uuid(),
+    $1
+)
+ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+RETURNING *;
+
+-- name: CreateChirpEntity :exec
+INSERT INTO chirp_entities (id, chirp_id, kind, start_offset, end_offset, text, value, hashtag_id)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+);
+
+-- name: GetEntitiesForChirps :many
+SELECT * FROM chirp_entities
+WHERE chirp_id = ANY(@chirp_ids::uuid[])
+ORDER BY chirp_id, start_offset;
+
+-- name: GetChirpsForHashtag :many
+SELECT * FROM chirps
+WHERE id IN (
+    SELECT e.chirp_id FROM chirp_entities e
+    JOIN hashtags h ON h.id = e.hashtag_id
+    WHERE h.tag = @tag
+)
+AND deleted_at IS NULL AND NOT scheduled
+AND (
+    visibility = 'public' OR user_id = @viewer_id
+    OR (visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = @viewer_id AND follows.followee_id = chirps.user_id
+    ))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = @viewer_id
+    OR blocks.blocker_id = @viewer_id AND blocks.blocked_id = chirps.user_id
+)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = @viewer_id AND mutes.muted_id = chirps.user_id
+)
+ORDER BY created_at DESC;
+
+-- name: DeleteEntitiesForChirp :exec
+DELETE FROM chirp_entities WHERE chirp_id = $1;
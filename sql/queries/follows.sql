@@ -0,0 +1,36 @@
+-- name: CreateFollow :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+SELECT @follower_id::uuid, @followee_id::uuid, NOW()
+WHERE NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = @followee_id AND blocks.blocked_id = @follower_id
+    OR blocks.blocker_id = @follower_id AND blocks.blocked_id = @followee_id
+);
+
+-- name: DeleteFollow :execrows
+DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: GetFollowers :many
+SELECT follower_id AS user_id, created_at FROM follows
+WHERE followee_id = @user_id
+AND (created_at, follower_id) < (@before_created_at::timestamp, @before_id::uuid)
+ORDER BY created_at DESC, follower_id DESC
+LIMIT @page_size;
+
+-- name: GetFollowing :many
+SELECT followee_id AS user_id, created_at FROM follows
+WHERE follower_id = @user_id
+AND (created_at, followee_id) < (@before_created_at::timestamp, @before_id::uuid)
+ORDER BY created_at DESC, followee_id DESC
+LIMIT @page_size;
+
+-- name: GetFollowCounts :many
+SELECT
+    u.id,
+    (SELECT COUNT(*) FROM follows WHERE followee_id = u.id) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follower_id = u.id) AS following_count
+FROM users u
+WHERE u.id = ANY(@ids::uuid[]);
+
+-- name: GetFolloweeIds :many
+SELECT followee_id FROM follows WHERE follower_id = $1;
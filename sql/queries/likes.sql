@@ -0,0 +1,7 @@
+-- name: CreateLike :execrows
+INSERT INTO likes (user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteLike :execrows
+DELETE FROM likes WHERE user_id = $1 AND chirp_id = $2;
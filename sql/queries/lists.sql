@@ -0,0 +1,111 @@
+-- name: CreateList :one
+INSERT INTO lists (id, owner_id, name, description, visibility, created_at, updated_at)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, NOW(), NOW())
+RETURNING *;
+
+-- name: GetList :one
+SELECT * FROM lists
+WHERE id = @id AND (visibility = 'public' OR owner_id = @viewer_id);
+
+-- name: UpdateList :one
+UPDATE lists SET name = $1, description = $2, visibility = $3, updated_at = NOW()
+WHERE id = $4 AND owner_id = $5
+RETURNING *;
+
+-- name: DeleteList :execrows
+DELETE FROM lists WHERE id = $1 AND owner_id = $2;
+
+-- name: GetListsForOwner :many
+SELECT * FROM lists
+WHERE owner_id = @owner_id
+AND (created_at, id) < (@before_created_at::timestamp, @before_id::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT @page_size;
+
+-- name: GetSubscribedLists :many
+SELECT l.*, s.created_at AS subscribed_at FROM lists l
+JOIN list_subscriptions s ON s.list_id = l.id
+WHERE s.user_id = @user_id AND l.visibility = 'public'
+AND (s.created_at, l.id) < (@before_created_at::timestamp, @before_id::uuid)
+ORDER BY s.created_at DESC, l.id DESC
+LIMIT @page_size;
+
+-- name: GetListCounts :many
+SELECT
+    l.id,
+    (SELECT COUNT(*) FROM list_members WHERE list_id = l.id) AS member_count,
+    (SELECT COUNT(*) FROM list_subscriptions WHERE list_id = l.id) AS subscriber_count,
+    EXISTS (
+        SELECT 1 FROM list_subscriptions
+        WHERE list_id = l.id AND user_id = @viewer_id
+    ) AS subscribed
+FROM lists l
+WHERE l.id = ANY(@ids::uuid[]);
+
+-- name: AddListMember :execrows
+INSERT INTO list_members (list_id, user_id, created_at)
+SELECT l.id, @user_id, NOW() FROM lists l
+WHERE l.id = @list_id AND l.owner_id = @owner_id
+AND (
+    (SELECT COUNT(*) FROM list_members WHERE list_id = @list_id) < @max_members::bigint
+    OR EXISTS (SELECT 1 FROM list_members WHERE list_id = @list_id AND user_id = @user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = @owner_id AND blocks.blocked_id = @user_id
+    OR blocks.blocker_id = @user_id AND blocks.blocked_id = @owner_id
+)
+ON CONFLICT (list_id, user_id) DO UPDATE SET list_id = EXCLUDED.list_id;
+
+-- name: RemoveListMember :execrows
+DELETE FROM list_members m
+USING lists l
+WHERE m.list_id = l.id AND l.id = @list_id AND l.owner_id = @owner_id AND m.user_id = @user_id;
+
+-- name: GetListMembers :many
+SELECT * FROM list_members
+WHERE list_id = @list_id
+AND (created_at, user_id) < (@before_created_at::timestamp, @before_id::uuid)
+ORDER BY created_at DESC, user_id DESC
+LIMIT @page_size;
+
+-- name: SubscribeToList :execrows
+INSERT INTO list_subscriptions (list_id, user_id, created_at)
+SELECT id, @user_id, NOW() FROM lists
+WHERE id = @list_id AND visibility = 'public' AND owner_id <> @user_id
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = lists.owner_id AND blocks.blocked_id = @user_id
+    OR blocks.blocker_id = @user_id AND blocks.blocked_id = lists.owner_id
+)
+ON CONFLICT (list_id, user_id) DO UPDATE SET list_id = EXCLUDED.list_id;
+
+-- name: UnsubscribeFromList :execrows
+DELETE FROM list_subscriptions WHERE list_id = $1 AND user_id = $2;
+
+-- name: DeleteListSubscriptions :exec
+DELETE FROM list_subscriptions WHERE list_id = $1;
+
+-- name: GetListTimeline :many
+SELECT c.id AS chirp_id, c.created_at FROM chirps c
+JOIN list_members m ON m.user_id = c.user_id AND m.list_id = @list_id
+WHERE c.deleted_at IS NULL AND NOT c.scheduled
+AND (c.created_at, c.id) < (@before_created_at::timestamp, @before_id::uuid)
+AND (
+    c.visibility = 'public' OR c.user_id = @viewer_id
+    OR (c.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = @viewer_id AND follows.followee_id = c.user_id
+    ))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = c.user_id AND blocks.blocked_id = @viewer_id
+    OR blocks.blocker_id = @viewer_id AND blocks.blocked_id = c.user_id
+)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = @viewer_id AND mutes.muted_id = c.user_id
+)
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT @page_size;
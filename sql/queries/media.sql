@@ -0,0 +1,35 @@
+-- name: CreateMedia :one
+INSERT INTO media (id, created_at, user_id, content_type, storage_key, thumbnail_key, width, height, size_bytes)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+RETURNING *;
+
+-- name: AttachMediaToChirp :execrows
+UPDATE media SET chirp_id = $1, position = $2
+WHERE id = $3 AND user_id = $4 AND chirp_id IS NULL
+AND NOT EXISTS (SELECT 1 FROM users WHERE users.avatar_media_id = media.id);
+
+-- name: GetMediaByKey :one
+SELECT * FROM media WHERE storage_key = $1 OR thumbnail_key = $1;
+
+-- name: IsAvatarMedia :one
+SELECT EXISTS (SELECT 1 FROM users WHERE avatar_media_id = $1);
+
+-- name: DeleteMediaForPurgedChirps :many
+DELETE FROM media
+WHERE chirp_id IN (SELECT id FROM chirps WHERE deleted_at < @deleted_before::timestamp)
+RETURNING storage_key, thumbnail_key;
+
+-- name: GetMediaForChirps :many
+SELECT * FROM media
+WHERE chirp_id = ANY(@chirp_ids::uuid[])
+ORDER BY chirp_id, position;
@@ -0,0 +1,19 @@
+-- name: SetChirpSensitive :one
+UPDATE chirps SET sensitive = $1, updated_at = NOW()
+WHERE id = $2 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (id, created_at, chirp_id, moderator_id, action, reason)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: GetModerationActionsForChirp :many
+SELECT * FROM moderation_actions WHERE chirp_id = $1 ORDER BY created_at;
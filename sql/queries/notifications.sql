@@ -0,0 +1,65 @@
+-- name: RecordNotification :exec
+INSERT INTO notifications (id, recipient_id, type, chirp_id, group_key, actor_ids, actor_count, created_at, updated_at)
+SELECT gen_random_uuid(), @recipient_id::uuid, @type::text, sqlc.narg('chirp_id')::uuid, @group_key::text, ARRAY[@actor_id::uuid], 1, NOW(), NOW()
+WHERE @recipient_id::uuid <> @actor_id::uuid
+AND NOT EXISTS (
+    SELECT 1 FROM notification_preferences p
+    WHERE p.user_id = @recipient_id::uuid AND p.type = @type::text AND NOT p.enabled
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = @recipient_id::uuid AND blocks.blocked_id = @actor_id::uuid
+    OR blocks.blocker_id = @actor_id::uuid AND blocks.blocked_id = @recipient_id::uuid
+)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = @recipient_id::uuid AND mutes.muted_id = @actor_id::uuid
+)
+ON CONFLICT (recipient_id, group_key) WHERE read_at IS NULL DO UPDATE SET
+    actor_count = notifications.actor_count + CASE WHEN @actor_id::uuid = ANY(notifications.actor_ids) THEN 0 ELSE 1 END,
+    actor_ids = CASE
+        WHEN @actor_id::uuid = ANY(notifications.actor_ids) THEN notifications.actor_ids
+        ELSE (@actor_id::uuid || notifications.actor_ids)[1:5]
+    END,
+    updated_at = NOW();
+
+-- name: GetNotifications :many
+SELECT * FROM notifications
+WHERE recipient_id = @recipient_id
+AND (updated_at, id) < (@before_updated_at::timestamp, @before_id::uuid)
+ORDER BY updated_at DESC, id DESC
+LIMIT @page_size;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications WHERE recipient_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+WHERE id = $1 AND recipient_id = $2;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications SET read_at = NOW()
+WHERE recipient_id = $1 AND read_at IS NULL;
+
+-- name: GetNotificationPreferences :many
+SELECT * FROM notification_preferences WHERE user_id = $1;
+
+-- name: UpsertNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, type, enabled)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled;
+
+-- name: GetChirpAuthor :one
+SELECT user_id FROM chirps WHERE id = $1;
+
+-- name: GetMentionRecipients :many
+SELECT u.id FROM users u
+JOIN chirps c ON c.id = @chirp_id
+WHERE lower(u.handle) = ANY(@handles::text[])
+AND (
+    c.visibility = 'public'
+    OR (c.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = u.id AND follows.followee_id = c.user_id
+    ))
+);
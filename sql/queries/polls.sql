@@ -0,0 +1,56 @@
+-- name: CreatePoll :one
+INSERT INTO polls (id, created_at, chirp_id, closes_at)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2
+)
+RETURNING *;
+
+-- name: CreatePollOption :exec
+INSERT INTO poll_options (id, poll_id, position, text)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3
+);
+
+-- name: GetPollByChirpId :one
+SELECT * FROM polls WHERE chirp_id = $1;
+
+-- name: GetPollsForChirps :many
+SELECT * FROM polls WHERE chirp_id = ANY(@chirp_ids::uuid[]);
+
+-- name: GetPollOptionsForPolls :many
+SELECT * FROM poll_options
+WHERE poll_id = ANY(@poll_ids::uuid[])
+ORDER BY poll_id, position;
+
+-- name: GetPollVoteCounts :many
+SELECT option_id, COUNT(*) AS votes FROM poll_votes
+WHERE poll_id = ANY(@poll_ids::uuid[])
+GROUP BY option_id;
+
+-- name: GetPollVotesByUser :many
+SELECT poll_id, option_id FROM poll_votes
+WHERE user_id = @user_id AND poll_id = ANY(@poll_ids::uuid[]);
+
+-- name: CreatePollVote :execrows
+INSERT INTO poll_votes (poll_id, user_id, option_id, created_at)
+SELECT p.id, @user_id::uuid, o.id, NOW()
+FROM polls p
+JOIN poll_options o ON o.poll_id = p.id
+WHERE p.id = @poll_id AND o.id = @option_id AND p.closes_at > NOW();
+
+-- name: StorePollTallies :exec
+UPDATE poll_options o
+SET vote_count = (SELECT COUNT(*) FROM poll_votes v WHERE v.option_id = o.id)
+WHERE o.poll_id IN (
+    SELECT id FROM polls WHERE closes_at <= NOW() AND finalized_at IS NULL
+);
+
+-- name: FinalizeClosedPolls :execrows
+UPDATE polls SET finalized_at = NOW()
+WHERE closes_at <= NOW() AND finalized_at IS NULL;
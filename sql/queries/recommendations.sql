@@ -0,0 +1,90 @@
+-- name: DeleteRecommendations :exec
+DELETE FROM user_recommendations;
+
+-- name: ComputeRecommendations :execrows
+INSERT INTO user_recommendations (user_id, candidate_id, score, mutual_follows, shared_hashtags, recent_chirps, computed_at)
+WITH mutuals AS (
+    SELECT f1.follower_id AS user_id, f2.followee_id AS candidate_id, COUNT(*) AS n
+    FROM follows f1
+    JOIN follows f2 ON f2.follower_id = f1.followee_id
+    GROUP BY f1.follower_id, f2.followee_id
+),
+user_hashtags AS (
+    SELECT DISTINCT c.user_id, e.hashtag_id
+    FROM chirp_entities e
+    JOIN chirps c ON c.id = e.chirp_id
+    WHERE e.hashtag_id IS NOT NULL AND c.created_at >= @hashtags_since::timestamp
+    AND c.deleted_at IS NULL AND NOT c.scheduled AND c.visibility = 'public'
+),
+shared AS (
+    SELECT a.user_id, b.user_id AS candidate_id, COUNT(*) AS n
+    FROM user_hashtags a
+    JOIN user_hashtags b ON b.hashtag_id = a.hashtag_id AND b.user_id <> a.user_id
+    GROUP BY a.user_id, b.user_id
+),
+activity AS (
+    SELECT user_id, COUNT(*) AS n
+    FROM chirps
+    WHERE created_at >= @active_since::timestamp
+    AND deleted_at IS NULL AND NOT scheduled AND visibility = 'public'
+    GROUP BY user_id
+),
+candidates AS (
+    SELECT
+        pairs.user_id,
+        pairs.candidate_id,
+        COALESCE(m.n, 0) AS mutual_follows,
+        COALESCE(s.n, 0) AS shared_hashtags,
+        COALESCE(a.n, 0) AS recent_chirps
+    FROM (SELECT user_id, candidate_id FROM mutuals UNION SELECT user_id, candidate_id FROM shared) pairs
+    LEFT JOIN mutuals m ON m.user_id = pairs.user_id AND m.candidate_id = pairs.candidate_id
+    LEFT JOIN shared s ON s.user_id = pairs.user_id AND s.candidate_id = pairs.candidate_id
+    LEFT JOIN activity a ON a.user_id = pairs.candidate_id
+    WHERE pairs.user_id <> pairs.candidate_id
+    AND NOT EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = pairs.user_id AND follows.followee_id = pairs.candidate_id
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocks.blocker_id = pairs.user_id AND blocks.blocked_id = pairs.candidate_id
+        OR blocks.blocker_id = pairs.candidate_id AND blocks.blocked_id = pairs.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = pairs.user_id AND mutes.muted_id = pairs.candidate_id
+    )
+),
+scored AS (
+    SELECT *,
+        @mutual_weight::float * mutual_follows
+            + @hashtag_weight::float * shared_hashtags
+            + @activity_weight::float * LN(1 + recent_chirps) AS score
+    FROM candidates
+),
+ranked AS (
+    SELECT *, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY score DESC, candidate_id) AS rank
+    FROM scored
+)
+SELECT user_id, candidate_id, score, mutual_follows, shared_hashtags, recent_chirps, NOW()
+FROM ranked
+WHERE rank <= @per_user;
+
+-- name: GetRecommendations :many
+SELECT r.* FROM user_recommendations r
+WHERE r.user_id = @user_id
+AND NOT EXISTS (
+    SELECT 1 FROM follows
+    WHERE follows.follower_id = r.user_id AND follows.followee_id = r.candidate_id
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = r.user_id AND blocks.blocked_id = r.candidate_id
+    OR blocks.blocker_id = r.candidate_id AND blocks.blocked_id = r.user_id
+)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = r.user_id AND mutes.muted_id = r.candidate_id
+)
+ORDER BY r.score DESC, r.candidate_id
+LIMIT @page_size;
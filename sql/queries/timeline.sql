@@ -0,0 +1,69 @@
+-- name: FanOutChirp :exec
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT @author_id::uuid, @chirp_id::uuid, @author_id::uuid, @created_at::timestamp
+UNION ALL
+SELECT f.follower_id, @chirp_id::uuid, @author_id::uuid, @created_at::timestamp
+FROM follows f
+JOIN users u ON u.id = f.followee_id
+WHERE f.followee_id = @author_id::uuid AND NOT u.fanout_on_read
+ON CONFLICT DO NOTHING;
+
+-- name: BackfillTimeline :exec
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT @follower_id::uuid, c.id, c.user_id, c.created_at
+FROM chirps c
+JOIN users u ON u.id = c.user_id
+WHERE c.user_id = @followee_id AND NOT u.fanout_on_read
+AND c.deleted_at IS NULL AND NOT c.scheduled
+ORDER BY c.created_at DESC
+LIMIT @backfill_size
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteTimelineEntriesForAuthor :exec
+DELETE FROM timeline_entries WHERE user_id = $1 AND author_id = $2;
+
+-- name: MarkFanoutOnRead :execrows
+UPDATE users SET fanout_on_read = TRUE
+WHERE id = @id AND NOT fanout_on_read
+AND (SELECT COUNT(*) FROM follows WHERE followee_id = @id) > @follower_limit::bigint;
+
+-- name: GetHomeTimeline :many
+SELECT t.chirp_id, t.created_at FROM timeline_entries t
+JOIN chirps c ON c.id = t.chirp_id
+WHERE t.user_id = @user_id
+AND (t.created_at, t.chirp_id) < (@before_created_at::timestamp, @before_id::uuid)
+AND c.deleted_at IS NULL AND NOT c.scheduled
+AND (
+    c.visibility = 'public' OR c.user_id = @user_id
+    OR (c.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = @user_id AND follows.followee_id = c.user_id
+    ))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = @user_id AND mutes.muted_id = t.author_id
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = c.user_id AND blocks.blocked_id = @user_id
+    OR blocks.blocker_id = @user_id AND blocks.blocked_id = c.user_id
+)
+UNION
+SELECT c.id AS chirp_id, c.created_at FROM chirps c
+JOIN follows f ON f.followee_id = c.user_id AND f.follower_id = @user_id
+JOIN users u ON u.id = c.user_id AND u.fanout_on_read
+WHERE c.deleted_at IS NULL AND NOT c.scheduled
+AND c.visibility IN ('public', 'followers')
+AND (c.created_at, c.id) < (@before_created_at::timestamp, @before_id::uuid)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = @user_id AND mutes.muted_id = c.user_id
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = c.user_id AND blocks.blocked_id = @user_id
+    OR blocks.blocker_id = @user_id AND blocks.blocked_id = c.user_id
+)
+ORDER BY created_at DESC, chirp_id DESC
+LIMIT @page_size;
@@ -0,0 +1,35 @@
+-- name: GetHashtagUsage :many
+WITH buckets AS (
+    SELECT
+        h.tag,
+        CASE
+            WHEN c.created_at >= @window_start::timestamp THEN -1
+            ELSE FLOOR(EXTRACT(EPOCH FROM (@window_start::timestamp - c.created_at)) / @window_seconds::float8)
+        END AS bucket,
+        COUNT(DISTINCT c.user_id) AS users
+    FROM chirp_entities e
+    JOIN hashtags h ON h.id = e.hashtag_id
+    JOIN chirps c ON c.id = e.chirp_id
+    WHERE c.created_at >= @baseline_start::timestamp
+    AND c.deleted_at IS NULL AND NOT c.scheduled AND c.visibility = 'public'
+    AND NOT EXISTS (SELECT 1 FROM suppressed_trends s WHERE s.term = h.tag)
+    GROUP BY h.tag, bucket
+)
+SELECT
+    tag,
+    COALESCE(SUM(users) FILTER (WHERE bucket < 0), 0)::bigint AS recent,
+    COALESCE(SUM(users) FILTER (WHERE bucket >= 0), 0)::bigint AS baseline
+FROM buckets
+GROUP BY tag
+HAVING SUM(users) FILTER (WHERE bucket < 0) > 0;
+
+-- name: GetSuppressedTrends :many
+SELECT * FROM suppressed_trends ORDER BY term;
+
+-- name: SuppressTrend :exec
+INSERT INTO suppressed_trends (term, created_at)
+VALUES ($1, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnsuppressTrend :execrows
+DELETE FROM suppressed_trends WHERE term = $1;
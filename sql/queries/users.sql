@@ -29,3 +29,49 @@ UPDATE users SET email = $1 WHERE email = $2;
 
 -- name: UpdateChirpyRed :exec
 UPDATE users SET is_chirpy_red = TRUE WHERE id = $1;
+
+-- name: GetUserById :one
+SELECT * FROM users WHERE id=$1;
+
+-- name: UpdateUserPreferences :one
+UPDATE users SET hide_sensitive = $1, dm_policy = $2, updated_at = NOW() WHERE id = $3
+RETURNING *;
+
+-- name: UpdateUserModerator :execrows
+UPDATE users SET is_moderator = $1, updated_at = NOW() WHERE id = $2;
+
+-- name: PinChirp :execrows
+UPDATE users SET pinned_chirp_id = @chirp_id, updated_at = NOW()
+WHERE id = @user_id AND EXISTS (
+    SELECT 1 FROM chirps
+    WHERE chirps.id = @chirp_id AND chirps.user_id = @user_id
+    AND chirps.deleted_at IS NULL AND NOT chirps.scheduled
+);
+
+-- name: UnpinChirp :execrows
+UPDATE users SET pinned_chirp_id = NULL, updated_at = NOW()
+WHERE id = $1 AND pinned_chirp_id = $2;
+
+-- name: UpdateUserProfile :one
+UPDATE users SET
+    handle = @handle,
+    display_name = @display_name,
+    bio = @bio,
+    avatar_media_id = sqlc.narg('avatar_media_id'),
+    updated_at = NOW()
+WHERE id = @id
+AND (sqlc.narg('avatar_media_id')::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM media
+    WHERE media.id = sqlc.narg('avatar_media_id') AND media.user_id = @id
+    AND media.chirp_id IS NULL
+))
+RETURNING *;
+
+-- name: GetUserByHandle :one
+SELECT * FROM users WHERE lower(handle) = lower($1);
+
+-- name: GetUserProfiles :many
+SELECT u.id, u.handle, u.display_name, u.bio, u.created_at, m.storage_key AS avatar_key
+FROM users u
+LEFT JOIN media m ON m.id = u.avatar_media_id
+WHERE u.id = ANY(@ids::uuid[]);
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE chirps
+    ADD COLUMN kind TEXT NOT NULL DEFAULT 'chirp' CHECK (kind IN ('chirp', 'rechirp', 'quote')),
+    ADD COLUMN referenced_chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX chirps_user_rechirp_idx ON chirps (user_id, referenced_chirp_id) WHERE kind = 'rechirp';
+
+-- +goose Down
+DROP INDEX chirps_user_rechirp_idx;
+ALTER TABLE chirps
+    DROP COLUMN referenced_chirp_id,
+    DROP COLUMN kind;
@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE hashtags (
+    id UUID PRIMARY KEY,
+    tag TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE chirp_entities (
+    id UUID PRIMARY KEY,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('hashtag', 'mention', 'url')),
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    value TEXT NOT NULL,
+    hashtag_id UUID REFERENCES hashtags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_entities_chirp_id_idx ON chirp_entities (chirp_id);
+CREATE INDEX chirp_entities_hashtag_id_idx ON chirp_entities (hashtag_id);
+
+-- +goose Down
+DROP TABLE chirp_entities;
+DROP TABLE hashtags;
@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX chirps_deleted_at_idx ON chirps (deleted_at) WHERE deleted_at IS NOT NULL;
+
+DROP INDEX chirps_user_rechirp_idx;
+CREATE UNIQUE INDEX chirps_user_rechirp_idx ON chirps (user_id, referenced_chirp_id) WHERE kind = 'rechirp' AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX chirps_user_rechirp_idx;
+CREATE UNIQUE INDEX chirps_user_rechirp_idx ON chirps (user_id, referenced_chirp_id) WHERE kind = 'rechirp';
+
+DROP INDEX chirps_deleted_at_idx;
+ALTER TABLE chirps DROP COLUMN deleted_at;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE chirps
+    ADD COLUMN scheduled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN publish_at TIMESTAMP;
+
+CREATE INDEX chirps_due_idx ON chirps (publish_at) WHERE scheduled;
+
+-- +goose Down
+DROP INDEX chirps_due_idx;
+ALTER TABLE chirps
+    DROP COLUMN publish_at,
+    DROP COLUMN scheduled;
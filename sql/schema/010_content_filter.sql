@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE content_filter_rules (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    term TEXT UNIQUE NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('mask', 'reject', 'flag'))
+);
+
+CREATE TABLE chirp_flags (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    term TEXT NOT NULL
+);
+
+CREATE INDEX chirp_flags_created_at_idx ON chirp_flags (created_at DESC);
+
+-- +goose Down
+DROP TABLE chirp_flags;
+DROP TABLE content_filter_rules;
@@ -0,0 +1,24 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN content_warning TEXT NOT NULL DEFAULT '';
+ALTER TABLE chirps ADD COLUMN sensitive BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE users ADD COLUMN hide_sensitive BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN is_moderator BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE moderation_actions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    chirp_id UUID NOT NULL,
+    moderator_id UUID NOT NULL REFERENCES users(id),
+    action TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX moderation_actions_chirp_id_idx ON moderation_actions (chirp_id, created_at);
+
+-- +goose Down
+DROP TABLE moderation_actions;
+ALTER TABLE users DROP COLUMN is_moderator;
+ALTER TABLE users DROP COLUMN hide_sensitive;
+ALTER TABLE chirps DROP COLUMN sensitive;
+ALTER TABLE chirps DROP COLUMN content_warning;
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN fanout_on_read BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE timeline_entries (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX timeline_entries_user_id_idx ON timeline_entries (user_id, created_at DESC, chirp_id DESC);
+CREATE INDEX timeline_entries_author_id_idx ON timeline_entries (user_id, author_id);
+CREATE INDEX chirps_user_id_created_at_idx ON chirps (user_id, created_at DESC, id DESC);
+
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT c.user_id, c.id, c.user_id, c.created_at FROM chirps c
+WHERE NOT c.scheduled AND c.deleted_at IS NULL
+UNION
+SELECT f.follower_id, c.id, c.user_id, c.created_at FROM follows f
+JOIN chirps c ON c.user_id = f.followee_id
+WHERE NOT c.scheduled AND c.deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX chirps_user_id_created_at_idx;
+DROP TABLE timeline_entries;
+ALTER TABLE users DROP COLUMN fanout_on_read;
@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    recipient_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+    group_key TEXT NOT NULL,
+    actor_ids UUID[] NOT NULL,
+    actor_count INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    read_at TIMESTAMP
+);
+
+-- Events of the same kind about the same thing are grouped into one unread
+-- notification until it is read.
+CREATE UNIQUE INDEX notifications_unread_group_idx ON notifications (recipient_id, group_key) WHERE read_at IS NULL;
+CREATE INDEX notifications_recipient_id_idx ON notifications (recipient_id, updated_at DESC, id DESC);
+
+CREATE TABLE notification_preferences (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, type)
+);
+
+-- +goose Down
+DROP TABLE notification_preferences;
+DROP TABLE notifications;
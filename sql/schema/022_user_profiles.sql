@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN handle TEXT;
+ALTER TABLE users ADD COLUMN display_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN bio TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN avatar_media_id UUID REFERENCES media(id) ON DELETE SET NULL;
+
+-- Handles are unique regardless of case, so @Gopher and @gopher are the
+-- same user.
+CREATE UNIQUE INDEX users_handle_idx ON users (lower(handle));
+
+-- +goose Down
+DROP INDEX users_handle_idx;
+ALTER TABLE users DROP COLUMN avatar_media_id;
+ALTER TABLE users DROP COLUMN bio;
+ALTER TABLE users DROP COLUMN display_name;
+ALTER TABLE users DROP COLUMN handle;
@@ -0,0 +1,8 @@
+-- +goose Up
+CREATE TABLE suppressed_trends (
+    term TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE suppressed_trends;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Rebuilt from scratch by the recommendations job, so reads never have to
+-- walk the follow graph.
+CREATE TABLE user_recommendations (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    candidate_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    score DOUBLE PRECISION NOT NULL,
+    mutual_follows INTEGER NOT NULL,
+    shared_hashtags INTEGER NOT NULL,
+    recent_chirps INTEGER NOT NULL,
+    computed_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, candidate_id)
+);
+
+CREATE INDEX user_recommendations_score_idx ON user_recommendations (user_id, score DESC);
+
+-- +goose Down
+DROP TABLE user_recommendations;
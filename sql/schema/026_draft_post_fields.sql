@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE drafts
+    ADD COLUMN in_reply_to_id UUID,
+    ADD COLUMN publish_at TIMESTAMP,
+    ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public',
+    ADD COLUMN content_warning TEXT NOT NULL DEFAULT '',
+    ADD COLUMN sensitive BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN has_poll BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN poll_options TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN poll_closes_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE drafts
+    DROP COLUMN in_reply_to_id,
+    DROP COLUMN publish_at,
+    DROP COLUMN visibility,
+    DROP COLUMN content_warning,
+    DROP COLUMN sensitive,
+    DROP COLUMN has_poll,
+    DROP COLUMN poll_options,
+    DROP COLUMN poll_closes_at;
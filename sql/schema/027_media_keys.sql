@@ -0,0 +1,7 @@
+-- +goose Up
+CREATE UNIQUE INDEX media_storage_key_idx ON media (storage_key);
+CREATE UNIQUE INDEX media_thumbnail_key_idx ON media (thumbnail_key);
+
+-- +goose Down
+DROP INDEX media_thumbnail_key_idx;
+DROP INDEX media_storage_key_idx;
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/AhmettCelik/web-server/internal/entities"
+	"github.com/google/uuid"
+)
+
+const (
+	streamReplaySize   = 1000
+	streamClientBuffer = 64
+	streamHeartbeat    = 15 * time.Second
+	streamRetry        = 3 * time.Second
+)
+
+const (
+	streamChirpCreated = "chirp.created"
+	streamChirpDeleted = "chirp.deleted"
+)
+
+// streamEvent is a chirp event pushed to live streams. Data is the JSON sent
+// to clients; the other fields are what streams filter on. Only public
+// chirps are streamed, so every event can go to every client.
+type streamEvent struct {
+	Type     string
+	AuthorID uuid.UUID
+	Hashtags []string
+	Data     []byte
+}
+
+// streamEpoch prefixes every event id sent to clients. Broker ids restart at
+// 1 with the process, so an id from before a restart must not be mistaken
+// for one issued since.
+var streamEpoch = time.Now().UnixNano()
+
+func streamEventId(id uint64) string {
+	return fmt.Sprintf("%d-%d", streamEpoch, id)
+}
+
+// parseStreamEventId returns the broker id in an event id sent by this
+// process. It returns false for malformed ids and ids from an earlier run.
+func parseStreamEventId(s string) (uint64, bool) {
+	epoch, id, ok := strings.Cut(s, "-")
+	if !ok || epoch != strconv.FormatInt(streamEpoch, 10) {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+func hashtagValues(body string) []string {
+	var tags []string
+	for _, entity := range entities.Parse(body) {
+		if entity.Kind == entities.KindHashtag {
+			tags = append(tags, entity.Value)
+		}
+	}
+	return tags
+}
+
+// streamChirpsCreated pushes newly visible chirps to live streams and
+// connections. It must only be called once the chirps are committed. Errors
+// are logged rather than returned, since the chirps were created either way.
+func (cfg *apiConfig) streamChirpsCreated(ctx context.Context, chirpsDb []database.Chirp) {
+	cfg.liveChirpsCreated(ctx, chirpsDb)
+
+	var public []database.Chirp
+	for _, chirpDb := range chirpsDb {
+		if chirpDb.Visibility == chirpVisibilityPublic && !chirpDb.Scheduled {
+			public = append(public, chirpDb)
+		}
+	}
+	if len(public) == 0 {
+		return
+	}
+
+	chirpsJson, err := cfg.chirpsResponse(ctx, uuid.Nil, public)
+	if err != nil {
+		log.Printf("Error streaming chirps: %v", err)
+		return
+	}
+
+	for i, chirpJson := range chirpsJson {
+		data, err := json.Marshal(chirpJson)
+		if err != nil {
+			log.Printf("Error streaming chirp: %v", err)
+			continue
+		}
+		cfg.stream.Publish(streamEvent{
+			Type:     streamChirpCreated,
+			AuthorID: public[i].UserID,
+			Hashtags: hashtagValues(public[i].Body),
+			Data:     data,
+		})
+	}
+}
+
+// streamChirpDeleted tells live streams and connections a chirp was
+// deleted. Streams only hear about public chirps.
+func (cfg *apiConfig) streamChirpDeleted(chirpDb database.Chirp) {
+	if chirpDb.Scheduled {
+		return
+	}
+
+	data, err := json.Marshal(map[string]string{
+		"id":      chirpDb.ID.String(),
+		"user_id": chirpDb.UserID.String(),
+	})
+	if err != nil {
+		log.Printf("Error streaming chirp deletion: %v", err)
+		return
+	}
+
+	cfg.live.Publish(liveEvent{
+		Type:       liveChirpDeleted,
+		ActorID:    chirpDb.UserID,
+		Visibility: chirpDb.Visibility,
+		Data:       data,
+	})
+	if chirpDb.Visibility != chirpVisibilityPublic {
+		return
+	}
+
+	cfg.stream.Publish(streamEvent{
+		Type:     streamChirpDeleted,
+		AuthorID: chirpDb.UserID,
+		Hashtags: hashtagValues(chirpDb.Body),
+		Data:     data,
+	})
+}
+
+// getStream pushes chirp events to the client as server-sent events. The
+// author and hashtag query parameters, which may be repeated, narrow the
+// stream to chirps matching any of them. Clients reconnecting with
+// Last-Event-ID get the events they missed from a bounded replay buffer; if
+// those are gone, or the id is from before a restart, a reset event tells
+// them to reload instead. Blocks and mutes of an authenticated caller are
+// applied as of when the stream opens.
+func (cfg *apiConfig) getStream(w http.ResponseWriter, req *http.Request) {
+	viewerId, err := cfg.viewer(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	authors := map[uuid.UUID]bool{}
+	for _, s := range req.URL.Query()["author"] {
+		authorId, err := uuid.Parse(s)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Cant parse uuid string")
+			return
+		}
+		authors[authorId] = true
+	}
+	hashtags := map[string]bool{}
+	for _, s := range req.URL.Query()["hashtag"] {
+		hashtags[entities.NormalizeHashtag(s)] = true
+	}
+
+	hidden := map[uuid.UUID]bool{}
+	if viewerId != uuid.Nil {
+		hiddenIds, err := cfg.db.GetHiddenAuthors(context.Background(), viewerId)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error opening stream")
+			log.Printf("Error getting hidden authors: %v", err)
+			return
+		}
+		for _, id := range hiddenIds {
+			hidden[id] = true
+		}
+	}
+
+	match := func(ev streamEvent) bool {
+		if hidden[ev.AuthorID] {
+			return false
+		}
+		if len(authors) == 0 && len(hashtags) == 0 {
+			return true
+		}
+		if authors[ev.AuthorID] {
+			return true
+		}
+		for _, tag := range ev.Hashtags {
+			if hashtags[tag] {
+				return true
+			}
+		}
+		return false
+	}
+
+	var lastEventId uint64
+	resumable := true
+	if s := req.Header.Get("Last-Event-ID"); s != "" {
+		lastEventId, resumable = parseStreamEventId(s)
+	}
+
+	sub, resumed := cfg.stream.Subscribe(lastEventId, streamClientBuffer, match)
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+	if !resumable || !resumed {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	if err := rc.Flush(); err != nil {
+		log.Printf("Error flushing stream: %v", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case ev, ok := <-sub.Events():
+			if !ok {
+				// The client fell too far behind. It reconnects with
+				// Last-Event-ID and catches up from the replay buffer.
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", streamEventId(ev.ID), ev.Value.Type, ev.Value.Data); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxThreadLength = 25
+
+// threadItemError reports why one chirp of a thread could not be posted.
+type threadItemError struct {
+	Index   int    `json:"index"`
+	Error   string `json:"error"`
+	Details any    `json:"details,omitempty"`
+}
+
+type threadErrorReport struct {
+	Error string            `json:"error"`
+	Items []threadItemError `json:"items"`
+}
+
+// threadInsertError remembers which chirp of a thread failed to insert.
+type threadInsertError struct {
+	index int
+	err   error
+}
+
+func (e *threadInsertError) Error() string {
+	return fmt.Sprintf("thread chirp %d: %v", e.index, e.err)
+}
+
+func (e *threadInsertError) Unwrap() error {
+	return e.err
+}
+
+func threadItemErrorFrom(index int, chirpErr *chirpError) threadItemError {
+	return threadItemError{Index: index, Error: chirpErr.msg, Details: chirpErr.details}
+}
+
+// checkThreadPart applies the rules that only matter inside a thread. Each
+// chirp after the first replies to the one before it, and the chain has to
+// go up together, so parts can't be scheduled or point elsewhere.
+func checkThreadPart(index int, post interpreter) *chirpError {
+	if post.Kind == chirpKindRechirp {
+		return &chirpError{status: http.StatusBadRequest, msg: "Threads can not contain rechirps"}
+	}
+	if post.PublishAt != nil {
+		return &chirpError{status: http.StatusBadRequest, msg: "Threads can not be scheduled"}
+	}
+	if index > 0 && post.InReplyToID != "" {
+		return &chirpError{status: http.StatusBadRequest, msg: "Only the first chirp of a thread can reply to another chirp"}
+	}
+	return nil
+}
+
+// postThread creates a chain of chirps, each replying to the one before it,
+// in one transaction. Every part gets the same checks as validatePost; if
+// any fails nothing is posted and the response lists each failing part.
+func (cfg *apiConfig) postThread(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating thread: %v", err)
+		return
+	}
+
+	thread := struct {
+		Chirps []interpreter `json:"chirps"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&thread); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if len(thread.Chirps) == 0 || len(thread.Chirps) > maxThreadLength {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("A thread needs 1 to %d chirps", maxThreadLength))
+		return
+	}
+
+	var prepared []preparedChirp
+	var itemErrors []threadItemError
+	for i, post := range thread.Chirps {
+		if chirpErr := checkThreadPart(i, post); chirpErr != nil {
+			itemErrors = append(itemErrors, threadItemErrorFrom(i, chirpErr))
+			continue
+		}
+
+		part, err := cfg.prepareChirp(context.Background(), userId, post)
+		var chirpErr *chirpError
+		if errors.As(err, &chirpErr) {
+			itemErrors = append(itemErrors, threadItemErrorFrom(i, chirpErr))
+			continue
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error validating chirp")
+			log.Printf("Error validating thread chirp %d: %v", i, err)
+			return
+		}
+		prepared = append(prepared, part)
+	}
+
+	if len(itemErrors) > 0 {
+		respondWithJSON(w, http.StatusBadRequest, threadErrorReport{Error: "Thread was not posted", Items: itemErrors})
+		return
+	}
+
+	// Every part would otherwise get the transaction's NOW(), and lists
+	// ordered by (created_at, id) would show the thread shuffled. Parts are
+	// a microsecond apart, the precision timestamps are stored with.
+	postedAt := time.Now().Truncate(time.Microsecond)
+
+	var chirpsDb []database.Chirp
+	err = cfg.withTx(context.Background(), func(q *database.Queries) error {
+		for i, part := range prepared {
+			if i > 0 {
+				part.params.InReplyToID = uuid.NullUUID{UUID: chirpsDb[i-1].ID, Valid: true}
+			}
+			part.params.CreatedAt = sql.NullTime{Time: postedAt.Add(time.Duration(i) * time.Microsecond), Valid: true}
+
+			chirpDb, err := insertChirp(context.Background(), q, part)
+			if err != nil {
+				return &threadInsertError{index: i, err: err}
+			}
+			chirpsDb = append(chirpsDb, chirpDb)
+		}
+		return nil
+	})
+	var insertErr *threadInsertError
+	var chirpErr *chirpError
+	if errors.As(err, &insertErr) && errors.As(err, &chirpErr) {
+		respondWithJSON(w, chirpErr.status, threadErrorReport{
+			Error: "Thread was not posted",
+			Items: []threadItemError{threadItemErrorFrom(insertErr.index, chirpErr)},
+		})
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating thread")
+		log.Printf("Error creating thread: %v", err)
+		return
+	}
+	cfg.streamChirpsCreated(context.Background(), chirpsDb)
+
+	chirpsJson, err := cfg.chirpsResponse(context.Background(), userId, chirpsDb)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading chirp details")
+		log.Printf("Error loading chirp details: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, chirpsJson)
+}
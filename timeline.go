@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/google/uuid"
+)
+
+// Home timelines are fanned out on write: publishing a chirp adds it to the
+// timeline of everyone following its author, so reading a timeline is one
+// index scan however many accounts the reader follows. Accounts with more
+// than fanoutFollowerLimit followers would make every post that expensive,
+// so they are switched to fan-out on read for good and their chirps are
+// merged in when timelines are read.
+const (
+	fanoutFollowerLimit  = 10000
+	timelineBackfillSize = 100
+)
+
+// fanOutChirp adds a newly published chirp to its author's timeline and to
+// the timelines of their followers.
+func fanOutChirp(ctx context.Context, q *database.Queries, chirpDb database.Chirp) error {
+	return q.FanOutChirp(ctx, database.FanOutChirpParams{
+		AuthorID:  chirpDb.UserID,
+		ChirpID:   chirpDb.ID,
+		CreatedAt: chirpDb.CreatedAt,
+	})
+}
+
+// startFollowing records a follow, notifies the followee and fills the
+// follower's timeline with the followee's recent chirps. Follows between
+// users where either has blocked the other are refused with errFollowBlocked.
+func startFollowing(ctx context.Context, q *database.Queries, followerId, followeeId uuid.UUID) error {
+	created, err := q.CreateFollow(ctx, database.CreateFollowParams{
+		FollowerID: followerId,
+		FolloweeID: followeeId,
+	})
+	if err != nil {
+		return err
+	}
+	if created == 0 {
+		return errFollowBlocked
+	}
+
+	if err := recordEvent(ctx, q, domainEvent{
+		Type:        notificationFollow,
+		ActorID:     followerId,
+		RecipientID: followeeId,
+	}); err != nil {
+		return err
+	}
+
+	if _, err := q.MarkFanoutOnRead(ctx, database.MarkFanoutOnReadParams{
+		ID:            followeeId,
+		FollowerLimit: fanoutFollowerLimit,
+	}); err != nil {
+		return err
+	}
+
+	return q.BackfillTimeline(ctx, database.BackfillTimelineParams{
+		FollowerID:   followerId,
+		FolloweeID:   followeeId,
+		BackfillSize: timelineBackfillSize,
+	})
+}
+
+// stopFollowing removes a follow along with the followee's chirps from the
+// follower's timeline. It reports whether there was a follow to remove.
+func stopFollowing(ctx context.Context, q *database.Queries, followerId, followeeId uuid.UUID) (bool, error) {
+	deleted, err := q.DeleteFollow(ctx, database.DeleteFollowParams{
+		FollowerID: followerId,
+		FolloweeID: followeeId,
+	})
+	if err != nil || deleted == 0 {
+		return false, err
+	}
+
+	err = q.DeleteTimelineEntriesForAuthor(ctx, database.DeleteTimelineEntriesForAuthorParams{
+		UserID:   followerId,
+		AuthorID: followeeId,
+	})
+	return err == nil, err
+}
+
+// getHomeTimeline lists chirps from the accounts the user follows and their
+// own, newest first.
+func (cfg *apiConfig) getHomeTimeline(w http.ResponseWriter, req *http.Request) {
+	userId, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or missing token")
+		log.Printf("Error authenticating timeline: %v", err)
+		return
+	}
+
+	limit, cursor, err := pageParams(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, err := cfg.db.GetHomeTimeline(context.Background(), database.GetHomeTimelineParams{
+		UserID:          userId,
+		BeforeCreatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		PageSize:        limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting timeline")
+		log.Printf("Error getting home timeline: %v", err)
+		return
+	}
+
+	var chirpIds []uuid.UUID
+	for _, entry := range entries {
+		chirpIds = append(chirpIds, entry.ChirpID)
+	}
+
+	chirpsDb, err := cfg.chirpsInOrder(context.Background(), userId, chirpIds)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting timeline")
+		log.Printf("Error getting timeline chirps: %v", err)
+		return
+	}
+
+	chirpsJson, err := cfg.chirpsResponse(context.Background(), userId, chirpsDb)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading chirp details")
+		log.Printf("Error loading chirp details: %v", err)
+		return
+	}
+
+	chirpsJson, err = cfg.filterSensitive(context.Background(), userId, chirpsJson)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error loading preferences")
+		log.Printf("Error loading preferences: %v", err)
+		return
+	}
+
+	timeline := page[chirp]{Items: []chirp{}}
+	timeline.Items = append(timeline.Items, chirpsJson...)
+	if len(entries) == int(limit) {
+		last := entries[len(entries)-1]
+		timeline.NextCursor = pageCursor{CreatedAt: last.CreatedAt, ID: last.ChirpID}.String()
+	}
+
+	respondWithJSON(w, http.StatusOK, timeline)
+}
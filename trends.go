@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+	"github.com/AhmettCelik/web-server/internal/entities"
+	"github.com/AhmettCelik/web-server/internal/trends"
+)
+
+const maxTrends = 10
+
+// trendsSnapshot is the latest ranking computed by the trends worker.
+type trendsSnapshot struct {
+	Trends     []trends.Trend
+	ComputedAt time.Time
+}
+
+type trend struct {
+	Tag   string  `json:"tag"`
+	Count int64   `json:"count"`
+	Score float64 `json:"score"`
+}
+
+type trendsResponse struct {
+	Window     string  `json:"window"`
+	ComputedAt string  `json:"computed_at,omitempty"`
+	Trends     []trend `json:"trends"`
+}
+
+type suppressedTrend struct {
+	Term      string `json:"term"`
+	CreatedAt string `json:"created_at"`
+}
+
+// refreshTrends ranks hashtags by their use in public chirps over the trend
+// window against the baseline period before it, and caches the result for
+// GET /api/trends. Suppressed terms are left out.
+func (cfg *apiConfig) refreshTrends(ctx context.Context) error {
+	now := time.Now()
+	usageDb, err := cfg.db.GetHashtagUsage(ctx, database.GetHashtagUsageParams{
+		WindowStart:   now.Add(-cfg.trendsWindow),
+		WindowSeconds: cfg.trendsWindow.Seconds(),
+		BaselineStart: now.Add(-cfg.trendsWindow - cfg.trendsBaseline),
+	})
+	if err != nil {
+		return err
+	}
+
+	var usage []trends.Usage
+	for _, u := range usageDb {
+		usage = append(usage, trends.Usage{Tag: u.Tag, Recent: u.Recent, Baseline: u.Baseline})
+	}
+
+	cfg.trends.Store(&trendsSnapshot{
+		Trends:     trends.Rank(usage, cfg.trendsWindow, cfg.trendsBaseline, maxTrends),
+		ComputedAt: now,
+	})
+	return nil
+}
+
+func (cfg *apiConfig) getTrends(w http.ResponseWriter, req *http.Request) {
+	response := trendsResponse{
+		Window: cfg.trendsWindow.String(),
+		Trends: []trend{},
+	}
+
+	if snapshot := cfg.trends.Load(); snapshot != nil {
+		response.ComputedAt = snapshot.ComputedAt.String()
+		for _, t := range snapshot.Trends {
+			response.Trends = append(response.Trends, trend{Tag: t.Tag, Count: t.Count, Score: t.Score})
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) getSuppressedTrends(w http.ResponseWriter, req *http.Request) {
+	if err := cfg.authenticateAdmin(req); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid api key")
+		return
+	}
+
+	suppressedDb, err := cfg.db.GetSuppressedTrends(context.Background())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting suppressed trends")
+		log.Printf("Error getting suppressed trends: %v", err)
+		return
+	}
+
+	suppressed := []suppressedTrend{}
+	for _, s := range suppressedDb {
+		suppressed = append(suppressed, suppressedTrend{Term: s.Term, CreatedAt: s.CreatedAt.String()})
+	}
+
+	respondWithJSON(w, http.StatusOK, suppressed)
+}
+
+// suppressTrend keeps a hashtag off the trends list and recomputes the list
+// so it disappears right away.
+func (cfg *apiConfig) suppressTrend(w http.ResponseWriter, req *http.Request) {
+	if err := cfg.authenticateAdmin(req); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid api key")
+		return
+	}
+
+	term := entities.NormalizeHashtag(req.PathValue("term"))
+	if term == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing term")
+		return
+	}
+
+	if err := cfg.db.SuppressTrend(context.Background(), term); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error suppressing trend")
+		log.Printf("Error suppressing trend: %v", err)
+		return
+	}
+
+	if err := cfg.refreshTrends(context.Background()); err != nil {
+		log.Printf("Error refreshing trends: %v", err)
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) unsuppressTrend(w http.ResponseWriter, req *http.Request) {
+	if err := cfg.authenticateAdmin(req); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid api key")
+		return
+	}
+
+	deleted, err := cfg.db.UnsuppressTrend(context.Background(), entities.NormalizeHashtag(req.PathValue("term")))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating trends")
+		log.Printf("Error unsuppressing trend: %v", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Term is not suppressed")
+		return
+	}
+
+	if err := cfg.refreshTrends(context.Background()); err != nil {
+		log.Printf("Error refreshing trends: %v", err)
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/AhmettCelik/web-server/internal/database"
+)
+
+// runWorker calls job every interval until ctx is cancelled. Errors are
+// logged and the job is retried on the next tick.
+func (cfg *apiConfig) runWorker(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job(ctx); err != nil {
+			log.Printf("Error running %s: %v", name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeDeletedChirps permanently removes chirps whose restore window has
+// passed, along with their media. Blobs are deleted only once the rows are
+// gone, so a failed purge never leaves media pointing at missing files; a
+// blob that fails to delete is logged and left behind.
+func (cfg *apiConfig) purgeDeletedChirps(ctx context.Context) error {
+	deletedBefore := time.Now().Add(-cfg.restoreWindow)
+
+	var mediaDb []database.DeleteMediaForPurgedChirpsRow
+	var purged int64
+	err := cfg.withTx(ctx, func(q *database.Queries) error {
+		var err error
+		mediaDb, err = q.DeleteMediaForPurgedChirps(ctx, deletedBefore)
+		if err != nil {
+			return err
+		}
+		purged, err = q.PurgeDeletedChirps(ctx, deletedBefore)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, m := range mediaDb {
+		for _, key := range []string{m.StorageKey, m.ThumbnailKey} {
+			if err := cfg.blobs.Delete(ctx, key); err != nil {
+				log.Printf("Error deleting blob %s: %v", key, err)
+			}
+		}
+	}
+
+	if purged > 0 {
+		log.Printf("Purged %d deleted chirps and %d media", purged, len(mediaDb))
+	}
+
+	return nil
+}
+
+// publishBatchSize caps how many scheduled chirps one tick publishes, so a
+// backlog is worked off over several ticks.
+const publishBatchSize = 100
+
+// publishScheduledChirps makes scheduled chirps visible once their publish
+// time has passed. Each chirp is published in its own transaction, so one
+// that fails is logged and retried on the next tick without holding up the
+// others.
+func (cfg *apiConfig) publishScheduledChirps(ctx context.Context) error {
+	dueIds, err := cfg.db.GetDueChirpIds(ctx, publishBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var published []database.Chirp
+	for _, chirpId := range dueIds {
+		var chirpDb database.Chirp
+		err := cfg.withTx(ctx, func(q *database.Queries) error {
+			var err error
+			chirpDb, err = q.PublishDueChirp(ctx, chirpId)
+			if err != nil {
+				return err
+			}
+			return publishChirp(ctx, q, chirpDb)
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// Cancelled or deleted since it was picked.
+			continue
+		}
+		if err != nil {
+			log.Printf("Error publishing scheduled chirp %s: %v", chirpId, err)
+			continue
+		}
+		published = append(published, chirpDb)
+	}
+
+	if len(published) > 0 {
+		log.Printf("Published %d scheduled chirps", len(published))
+		cfg.streamChirpsCreated(ctx, published)
+	}
+
+	return nil
+}
+
+// finalizeClosedPolls stores the tallies of polls that have closed, so they
+// no longer need to be counted on every read.
+func (cfg *apiConfig) finalizeClosedPolls(ctx context.Context) error {
+	var finalized int64
+	err := cfg.withTx(ctx, func(q *database.Queries) error {
+		if err := q.StorePollTallies(ctx); err != nil {
+			return err
+		}
+		var err error
+		finalized, err = q.FinalizeClosedPolls(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if finalized > 0 {
+		log.Printf("Finalized %d polls", finalized)
+	}
+
+	return nil
+}